@@ -0,0 +1,214 @@
+// Package main implements otelconfig-check.
+//
+// otelconfig-check reads the same env vars as oteltrace.TraceStart, prints
+// the effective configuration, exports one test span and reports
+// success or failure with hints. It is meant for init containers and
+// runbooks:
+//
+//	export OTEL_EXPORTER_OTLP_ENDPOINT=http://otel-collector:4317
+//	otelconfig-check
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/udhos/otelconfig/oteltrace"
+	"go.opentelemetry.io/otel"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// envPrefixes selects env vars printed as effective configuration.
+var envPrefixes = []string{"OTEL_", "OTELCONFIG_", "DD_", "ELASTIC_APM_", "UPTRACE_"}
+
+// secretEnv holds env vars whose values must not be printed.
+var secretEnv = []string{"HEADERS", "TOKEN", "API_KEY", "DSN"}
+
+func main() {
+	me := "otelconfig-check"
+
+	var (
+		service string
+		preset  string
+		timeout time.Duration
+	)
+
+	flag.StringVar(&service, "service", me, "default service name")
+	flag.StringVar(&preset, "preset", "", "preset name, OTELCONFIG_PRESET takes precedence")
+	flag.DurationVar(&timeout, "timeout", 10*time.Second, "timeout for the test export")
+	flag.Parse()
+
+	// exporter timeout shorter than flush timeout, in order to
+	// report the actual export error instead of flush deadline.
+	if os.Getenv("OTEL_EXPORTER_OTLP_TIMEOUT") == "" {
+		os.Setenv("OTEL_EXPORTER_OTLP_TIMEOUT", strconv.FormatInt(timeout.Milliseconds(), 10))
+	}
+
+	fmt.Println("effective configuration:")
+	for _, e := range configEnv() {
+		fmt.Printf("  %s\n", e)
+	}
+
+	// capture export errors reported asynchronously by the SDK
+	var (
+		errLock   sync.Mutex
+		exportErr error
+	)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		errLock.Lock()
+		if exportErr == nil {
+			exportErr = err
+		}
+		errLock.Unlock()
+	}))
+
+	options := oteltrace.TraceOptions{
+		DefaultService: service,
+		Preset:         preset,
+		Debug:          true,
+		// the test span must be exported regardless of OTEL_TRACES_SAMPLER
+		SamplerFunc: func(tracesdk.SamplingParameters) tracesdk.SamplingDecision {
+			return tracesdk.RecordAndSample
+		},
+	}
+
+	_, tracer, cancel, r, errTracer := oteltrace.TraceStartReport(context.Background(), options)
+	if errTracer != nil {
+		fail(errTracer)
+	}
+
+	fmt.Println("configured:")
+	fmt.Printf("  exporter=%s endpoint='%s' source='%s' tls=%t\n",
+		r.Exporter, r.Endpoint, r.EndpointSource, r.TLS)
+	fmt.Printf("  sampler=%s\n", r.EnvSampler)
+	fmt.Printf("  check_sampler=%s (replaces sampler to export the test span)\n", r.Sampler)
+	fmt.Printf("  propagator_fields=%s\n", strings.Join(r.PropagatorFields, ","))
+	for _, a := range r.Resource {
+		fmt.Printf("  resource: %s=%s\n", a.Key, a.Value.Emit())
+	}
+
+	_, span := tracer.Start(context.Background(), me)
+	span.End()
+
+	if !span.SpanContext().IsSampled() {
+		fail(fmt.Errorf("test span not sampled: nothing was exported"))
+	}
+
+	ctx, cancelFlush := context.WithTimeout(context.Background(), 2*timeout)
+	defer cancelFlush()
+
+	errFlush := oteltrace.ForceFlush(ctx)
+
+	errLock.Lock()
+	if errFlush == nil {
+		errFlush = exportErr
+	}
+	errLock.Unlock()
+
+	if errFlush != nil {
+		fail(errFlush)
+	}
+
+	cancel()
+
+	fmt.Printf("SUCCESS: test span exported: trace_id=%s\n",
+		span.SpanContext().TraceID())
+}
+
+// fail reports err with hints and exits.
+func fail(err error) {
+	fmt.Printf("FAILURE: %v\n", err)
+	for _, h := range hints(err) {
+		fmt.Printf("  hint: %s\n", h)
+	}
+	os.Exit(1)
+}
+
+// configEnv lists relevant env vars, masking secrets.
+func configEnv() []string {
+	var list []string
+	for _, e := range os.Environ() {
+		key, value, _ := strings.Cut(e, "=")
+		if !hasAnyPrefix(key, envPrefixes) {
+			continue
+		}
+		for _, s := range secretEnv {
+			if strings.Contains(key, s) && value != "" {
+				value = "<redacted>"
+				break
+			}
+		}
+		list = append(list, key+"="+value)
+	}
+	if len(list) == 0 {
+		list = append(list, "no OTEL_ or OTELCONFIG_ env var set, using defaults")
+	}
+	sort.Strings(list)
+	return list
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// errorHints maps error message fragments to hints.
+var errorHints = []struct {
+	fragments []string
+	hint      string
+}{
+	{[]string{"endpoint must", "endpoint port must", "endpoint scheme must"},
+		"fix the endpoint format, like http://otel-collector:4317"},
+	{[]string{"connection refused"},
+		"nothing is listening at the endpoint: check host and port in OTEL_EXPORTER_OTLP_ENDPOINT and that the collector is running"},
+	{[]string{"no such host"},
+		"endpoint host name does not resolve: check OTEL_EXPORTER_OTLP_ENDPOINT and DNS"},
+	{[]string{"unimplemented", "404", "http2", "malformed http response"},
+		"protocol mismatch: OTLP gRPC usually listens on port 4317 and OTLP HTTP on 4318, check OTELCONFIG_EXPORTER=grpc|http"},
+	{[]string{"x509", "certificate", "tls", "handshake"},
+		"TLS failure: use https:// only for TLS collectors, check OTEL_EXPORTER_OTLP_CERTIFICATE and client certificate/key"},
+	{[]string{"unauthenticated", "permission", "401", "403"},
+		"authentication failure: check OTEL_EXPORTER_OTLP_HEADERS or the preset credentials"},
+	{[]string{"unrecognized exporter"},
+		"OTELCONFIG_EXPORTER must be one of: grpc, http, jaeger, stdout"},
+	{[]string{"preset"},
+		"check OTELCONFIG_PRESET or -preset"},
+}
+
+// hints suggests fixes for common export errors.
+func hints(err error) []string {
+	msg := strings.ToLower(err.Error())
+
+	var list []string
+
+	for _, h := range errorHints {
+		for _, f := range h.fragments {
+			if strings.Contains(msg, f) {
+				list = append(list, h.hint)
+				break
+			}
+		}
+	}
+
+	if len(list) == 0 && strings.Contains(msg, "deadline") {
+		list = append(list, "export timed out: check network reachability, firewalls, and whether the endpoint expects TLS (https://) or plaintext (http://)")
+	}
+
+	if len(list) == 0 {
+		log.Printf("no hint for error: %v", err)
+	}
+
+	return list
+}
@@ -0,0 +1,155 @@
+package oteltrace
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// contextWithEnvBaggage adds to ctx the baggage members from OTELCONFIG_BAGGAGE.
+// The env var uses the W3C baggage header format:
+//
+//	export OTELCONFIG_BAGGAGE=run.id=1234,job.name=nightly-report
+func contextWithEnvBaggage(ctx context.Context, debug bool) (context.Context, error) {
+	const me = "contextWithEnvBaggage"
+
+	str := getEnv(me, "OTELCONFIG_BAGGAGE", debug)
+	if str == "" {
+		return ctx, nil
+	}
+
+	bag, err := baggage.Parse(str)
+	if err != nil {
+		return ctx, fmt.Errorf("%s: bad OTELCONFIG_BAGGAGE='%s': %w", me, str, err)
+	}
+
+	// merge with baggage already present in ctx
+	current := baggage.FromContext(ctx)
+	for _, m := range bag.Members() {
+		current, err = current.SetMember(m)
+		if err != nil {
+			return ctx, fmt.Errorf("%s: baggage member '%s': %w", me, m.Key(), err)
+		}
+	}
+
+	return baggage.ContextWithBaggage(ctx, current), nil
+}
+
+// baggageLimits restricts baggage propagated by this service.
+type baggageLimits struct {
+	allowKeys  []string // nil means any key
+	maxMembers int      // 0 means unlimited
+	maxBytes   int      // 0 means unlimited, size of encoded header
+}
+
+// baggageLimitsFromOptions merges options with env vars:
+//
+//	OTELCONFIG_BAGGAGE_ALLOW=key1,key2
+//	OTELCONFIG_BAGGAGE_MAX_MEMBERS=10
+//	OTELCONFIG_BAGGAGE_MAX_BYTES=1024
+func baggageLimitsFromOptions(options TraceOptions) baggageLimits {
+	const me = "baggageLimitsFromOptions"
+
+	limits := baggageLimits{
+		allowKeys:  options.BaggageAllowKeys,
+		maxMembers: options.BaggageMaxMembers,
+		maxBytes:   options.BaggageMaxBytes,
+	}
+
+	if str := getEnv(me, "OTELCONFIG_BAGGAGE_ALLOW", options.Debug); str != "" {
+		limits.allowKeys = strings.FieldsFunc(str, func(c rune) bool { return c == ',' || c == ' ' })
+	}
+
+	envInt := func(key string, value *int) {
+		str := getEnv(me, key, options.Debug)
+		if str == "" {
+			return
+		}
+		v, err := strconv.Atoi(str)
+		if err != nil || v < 0 {
+			log.Printf("%s: bad %s='%s': %v", me, key, str, err)
+			return
+		}
+		*value = v
+	}
+
+	envInt("OTELCONFIG_BAGGAGE_MAX_MEMBERS", &limits.maxMembers)
+	envInt("OTELCONFIG_BAGGAGE_MAX_BYTES", &limits.maxBytes)
+
+	return limits
+}
+
+func (l baggageLimits) enabled() bool {
+	return l.allowKeys != nil || l.maxMembers > 0 || l.maxBytes > 0
+}
+
+// apply removes from bag the members not allowed, and the members
+// exceeding the limits, taken in key order.
+func (l baggageLimits) apply(bag baggage.Baggage) baggage.Baggage {
+	members := bag.Members()
+	if len(members) == 0 {
+		return bag
+	}
+
+	sort.Slice(members, func(i, j int) bool { return members[i].Key() < members[j].Key() })
+
+	var result baggage.Baggage
+	var size int
+
+	for _, m := range members {
+		if l.allowKeys != nil && !slices.Contains(l.allowKeys, m.Key()) {
+			continue
+		}
+		if l.maxMembers > 0 && result.Len() >= l.maxMembers {
+			break
+		}
+		memberSize := len(m.String())
+		if result.Len() > 0 {
+			memberSize++ // comma separator
+		}
+		if l.maxBytes > 0 && size+memberSize > l.maxBytes {
+			continue // a smaller member may still fit
+		}
+		next, err := result.SetMember(m)
+		if err != nil {
+			continue
+		}
+		result = next
+		size += memberSize
+	}
+
+	return result
+}
+
+// baggageLimitPropagator enforces baggage limits both on injection,
+// restricting what is propagated downstream, and on extraction,
+// preventing unbounded growth from upstream.
+type baggageLimitPropagator struct {
+	propagation.TextMapPropagator
+	limits baggageLimits
+}
+
+// Inject implements propagation.TextMapPropagator.
+func (p baggageLimitPropagator) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	p.TextMapPropagator.Inject(p.limit(ctx), carrier)
+}
+
+// Extract implements propagation.TextMapPropagator.
+func (p baggageLimitPropagator) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return p.limit(p.TextMapPropagator.Extract(ctx, carrier))
+}
+
+func (p baggageLimitPropagator) limit(ctx context.Context) context.Context {
+	bag := baggage.FromContext(ctx)
+	if bag.Len() == 0 {
+		return ctx
+	}
+	return baggage.ContextWithBaggage(ctx, p.limits.apply(bag))
+}
@@ -0,0 +1,52 @@
+package oteltrace
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel/baggage"
+)
+
+func TestBaggageLimitsApply(t *testing.T) {
+	table := []struct {
+		name   string
+		limits baggageLimits
+		input  string
+		want   string
+	}{
+		{"no limits", baggageLimits{}, "a=1,b=2", "a=1,b=2"},
+		{"empty", baggageLimits{maxMembers: 1}, "", ""},
+		{"allow keys", baggageLimits{allowKeys: []string{"b"}}, "a=1,b=2,c=3", "b=2"},
+		{"allow none", baggageLimits{allowKeys: []string{}}, "a=1", ""},
+		{"max members keeps sorted keys", baggageLimits{maxMembers: 2}, "c=3,a=1,b=2", "a=1,b=2"},
+		{"max bytes", baggageLimits{maxBytes: 7}, "a=1,b=2,c=3", "a=1,b=2"},
+		{"max bytes skips large member", baggageLimits{maxBytes: 7}, "a=1,b=22222,c=3", "a=1,c=3"},
+		{"max bytes too small", baggageLimits{maxBytes: 2}, "a=1", ""},
+		{"allow and max members", baggageLimits{allowKeys: []string{"a", "c"}, maxMembers: 1}, "a=1,b=2,c=3", "a=1"},
+	}
+
+	for _, data := range table {
+		t.Run(data.name, func(t *testing.T) {
+			bag, err := baggage.Parse(data.input)
+			if err != nil {
+				t.Fatalf("parse baggage '%s': %v", data.input, err)
+			}
+			want, err := baggage.Parse(data.want)
+			if err != nil {
+				t.Fatalf("parse baggage '%s': %v", data.want, err)
+			}
+			got := data.limits.apply(bag)
+			if got.Len() != want.Len() {
+				t.Fatalf("got '%s', want '%s'", got, want)
+			}
+			for _, m := range want.Members() {
+				if got.Member(m.Key()).Value() != m.Value() {
+					t.Errorf("got '%s', want '%s'", got, want)
+				}
+			}
+			if data.limits.maxBytes > 0 && len(got.String()) > data.limits.maxBytes {
+				t.Errorf("size %d exceeds max bytes %d: '%s'",
+					len(got.String()), data.limits.maxBytes, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package oteltrace
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// clockProvider wraps a TracerProvider in order to take span start, end
+// and event timestamps from a custom time source, unless the caller
+// provides explicit timestamps.
+type clockProvider struct {
+	trace.TracerProvider
+	now func() time.Time
+}
+
+// Tracer implements trace.TracerProvider.
+func (p clockProvider) Tracer(name string, options ...trace.TracerOption) trace.Tracer {
+	return clockTracer{Tracer: p.TracerProvider.Tracer(name, options...), provider: p}
+}
+
+type clockTracer struct {
+	trace.Tracer
+	provider clockProvider
+}
+
+// Start implements trace.Tracer.
+func (t clockTracer) Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	// caller options come last, so an explicit timestamp wins
+	opts = append([]trace.SpanStartOption{trace.WithTimestamp(t.provider.now())}, opts...)
+	if parent, ok := trace.SpanFromContext(ctx).(clockSpan); ok {
+		// the SDK counts children only of its own span type
+		ctx = trace.ContextWithSpan(ctx, parent.Span)
+	}
+	ctx, span := t.Tracer.Start(ctx, spanName, opts...)
+	s := clockSpan{Span: span, provider: t.provider}
+	return trace.ContextWithSpan(ctx, s), s
+}
+
+type clockSpan struct {
+	trace.Span
+	provider clockProvider
+}
+
+// End implements trace.Span.
+func (s clockSpan) End(options ...trace.SpanEndOption) {
+	options = append([]trace.SpanEndOption{trace.WithTimestamp(s.provider.now())}, options...)
+	s.Span.End(options...)
+}
+
+// AddEvent implements trace.Span.
+func (s clockSpan) AddEvent(name string, options ...trace.EventOption) {
+	options = append([]trace.EventOption{trace.WithTimestamp(s.provider.now())}, options...)
+	s.Span.AddEvent(name, options...)
+}
+
+// RecordError implements trace.Span.
+func (s clockSpan) RecordError(err error, options ...trace.EventOption) {
+	options = append([]trace.EventOption{trace.WithTimestamp(s.provider.now())}, options...)
+	s.Span.RecordError(err, options...)
+}
+
+// TracerProvider implements trace.Span.
+func (s clockSpan) TracerProvider() trace.TracerProvider {
+	return s.provider
+}
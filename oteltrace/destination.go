@@ -0,0 +1,138 @@
+package oteltrace
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Destination is an additional export destination receiving every
+// exported span, like a tenant-specific vendor account alongside the
+// internal collector. Each destination has its own exporter and batch
+// processor, so a slow destination does not hold back the others.
+//
+// Destinations are isolated from OTEL_EXPORTER_OTLP_ENDPOINT,
+// OTEL_EXPORTER_OTLP_HEADERS and the TLS certificate env vars, which
+// apply only to the main exporter.
+type Destination struct {
+	// Name identifies the destination in logs and errors.
+	Name string
+
+	// Exporter is grpc (default), http, jaeger or stdout.
+	Exporter string
+
+	// Endpoint is a URL like https://vendor.example.com:4317.
+	// The scheme selects TLS (https) or plaintext (http).
+	// For the http exporter, it is a base URL: /v1/traces is appended
+	// to its path, like https://vendor.example.com/otlp/v1/traces.
+	Endpoint string
+
+	// Headers are sent only to this destination, like vendor API keys.
+	Headers map[string]string
+
+	// TLSConfig customizes TLS for https endpoints. If nil, the system
+	// root CAs are used.
+	TLSConfig *tls.Config
+}
+
+// destinationConfigs builds export configs for options.Destinations.
+func destinationConfigs(options TraceOptions) ([]exportConfig, error) {
+	const me = "destinationConfigs"
+
+	var list []exportConfig
+
+	for i, d := range options.Destinations {
+		name := d.Name
+		if name == "" {
+			name = fmt.Sprintf("%d", i)
+		}
+		source := fmt.Sprintf("Destinations[%s].Endpoint", name)
+
+		switch d.Exporter {
+		case "", "grpc", "http", "jaeger", "stdout":
+		default:
+			return nil, fmt.Errorf("%s: Destinations[%s].Exporter: unrecognized exporter type: '%s'",
+				me, name, d.Exporter)
+		}
+		if d.Endpoint == "" && d.Exporter != "stdout" {
+			return nil, fmt.Errorf("%s: %s: missing endpoint", me, source)
+		}
+		if err := validateEndpoint(d.Exporter, source, d.Endpoint, false); err != nil {
+			return nil, fmt.Errorf("%s: %w", me, err)
+		}
+
+		headers := d.Headers
+		if headers == nil {
+			headers = map[string]string{} // do not inherit OTEL_EXPORTER_OTLP_HEADERS
+		}
+
+		cfg := exportConfig{
+			exporter:         d.Exporter,
+			endpoint:         d.Endpoint,
+			endpointSource:   source,
+			endpointExplicit: d.Endpoint != "",
+			headers:          headers,
+			headersExplicit:  true,
+			debug:            options.Debug,
+		}
+
+		if strings.HasPrefix(strings.ToLower(d.Endpoint), "https://") {
+			cfg.tlsConfig = d.TLSConfig
+			if cfg.tlsConfig == nil {
+				cfg.tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+			}
+		}
+
+		if options.Debug {
+			log.Printf("%s: destination '%s': exporter='%s' endpoint='%s' tls=%t",
+				me, name, d.Exporter, d.Endpoint, cfg.tlsConfig != nil)
+		}
+
+		list = append(list, cfg)
+	}
+
+	return list, nil
+}
+
+// fanoutProcessor hands spans to all export processors, one per
+// destination.
+type fanoutProcessor struct {
+	processors []tracesdk.SpanProcessor
+}
+
+// OnStart implements tracesdk.SpanProcessor.
+func (p fanoutProcessor) OnStart(parent context.Context, s tracesdk.ReadWriteSpan) {
+	for _, proc := range p.processors {
+		proc.OnStart(parent, s)
+	}
+}
+
+// OnEnd implements tracesdk.SpanProcessor.
+func (p fanoutProcessor) OnEnd(s tracesdk.ReadOnlySpan) {
+	for _, proc := range p.processors {
+		proc.OnEnd(s)
+	}
+}
+
+// Shutdown implements tracesdk.SpanProcessor.
+func (p fanoutProcessor) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, proc := range p.processors {
+		errs = append(errs, proc.Shutdown(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// ForceFlush implements tracesdk.SpanProcessor.
+func (p fanoutProcessor) ForceFlush(ctx context.Context) error {
+	var errs []error
+	for _, proc := range p.processors {
+		errs = append(errs, proc.ForceFlush(ctx))
+	}
+	return errors.Join(errs...)
+}
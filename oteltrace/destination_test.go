@@ -0,0 +1,94 @@
+package oteltrace
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestHTTPDestinationPath(t *testing.T) {
+	paths := make(chan string, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths <- r.URL.Path
+	}))
+	defer server.Close()
+
+	table := []struct {
+		name     string
+		basePath string
+		want     string
+	}{
+		{"no path", "", "/v1/traces"},
+		{"root path", "/", "/v1/traces"},
+		{"base path", "/otlp", "/otlp/v1/traces"},
+		{"base path trailing slash", "/otlp/", "/otlp/v1/traces"},
+	}
+
+	for _, data := range table {
+		t.Run(data.name, func(t *testing.T) {
+			options := TraceOptions{
+				Destinations: []Destination{{
+					Name:     "vendor",
+					Exporter: "http",
+					Endpoint: server.URL + data.basePath,
+				}},
+			}
+
+			dests, err := destinationConfigs(options)
+			if err != nil {
+				t.Fatalf("destination config: %v", err)
+			}
+
+			exp, err := createExporter(dests[0])
+			if err != nil {
+				t.Fatalf("create exporter: %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			span := tracetest.SpanStub{Name: "test"}.Snapshot()
+			if err := exp.ExportSpans(ctx, []tracesdk.ReadOnlySpan{span}); err != nil {
+				t.Fatalf("export: %v", err)
+			}
+			exp.Shutdown(ctx)
+
+			if got := <-paths; got != data.want {
+				t.Errorf("request path: got '%s', want '%s'", got, data.want)
+			}
+		})
+	}
+}
+
+func TestDestinationConfigErrors(t *testing.T) {
+	table := []struct {
+		name        string
+		destination Destination
+		valid       bool
+	}{
+		{"grpc", Destination{Exporter: "grpc", Endpoint: "http://vendor:4317"}, true},
+		{"default exporter", Destination{Endpoint: "http://vendor:4317"}, true},
+		{"stdout without endpoint", Destination{Exporter: "stdout"}, true},
+		{"missing endpoint", Destination{Exporter: "http"}, false},
+		{"unknown exporter", Destination{Exporter: "foo", Endpoint: "http://vendor:4317"}, false},
+	}
+
+	for _, data := range table {
+		t.Run(data.name, func(t *testing.T) {
+			options := TraceOptions{Destinations: []Destination{data.destination}}
+			_, err := destinationConfigs(options)
+			if data.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !data.valid && err == nil {
+				t.Errorf("expected error for destination %+v", data.destination)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package oteltrace
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// validateEndpoints checks the configured endpoints early, in order to
+// report misformatted endpoints with clear errors.
+func validateEndpoints(cfg exportConfig) error {
+	const me = "validateEndpoints"
+
+	if err := validateEndpoint(cfg.exporter, cfg.endpointSource, cfg.endpoint, false); err != nil {
+		return err
+	}
+
+	if cfg.exporter == "jaeger" {
+		return nil // jaeger exporter does not use OTEL_EXPORTER_OTLP_TRACES_ENDPOINT
+	}
+
+	traces := getEnv(me, "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", cfg.debug)
+
+	return validateEndpoint(cfg.exporter, "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", traces, true)
+}
+
+// validateEndpoint validates endpoint for exporter.
+// signal tells the endpoint is a per-signal full URL, rather than a base URL.
+func validateEndpoint(exporter, source, endpoint string, signal bool) error {
+	if endpoint == "" || exporter == "stdout" {
+		return nil
+	}
+
+	if !strings.Contains(endpoint, "://") {
+		return fmt.Errorf("%s='%s': endpoint must be a URL with scheme, like http://host:port or https://host:port",
+			source, endpoint)
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("%s='%s': bad endpoint URL: %w", source, endpoint, err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+	default:
+		return fmt.Errorf("%s='%s': endpoint scheme must be http or https, got '%s'",
+			source, endpoint, u.Scheme)
+	}
+
+	if u.Hostname() == "" {
+		return fmt.Errorf("%s='%s': endpoint must include a host", source, endpoint)
+	}
+
+	if p := u.Port(); p != "" {
+		port, errPort := strconv.Atoi(p)
+		if errPort != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("%s='%s': endpoint port must be a number from 1 to 65535, got '%s'",
+				source, endpoint, p)
+		}
+	}
+
+	path := strings.TrimSuffix(u.Path, "/")
+
+	switch exporter {
+	case "", "grpc":
+		if path != "" {
+			return fmt.Errorf("%s='%s': gRPC endpoint must not include a URL path, got '%s'",
+				source, endpoint, u.Path)
+		}
+		if u.RawQuery != "" || u.Fragment != "" {
+			return fmt.Errorf("%s='%s': gRPC endpoint must not include a URL query or fragment",
+				source, endpoint)
+		}
+	case "http":
+		if !signal && strings.HasSuffix(path, "/v1/traces") {
+			return fmt.Errorf("%s='%s': HTTP endpoint must not include /v1/traces, it is appended automatically; use OTEL_EXPORTER_OTLP_TRACES_ENDPOINT for a full URL",
+				source, endpoint)
+		}
+	case "jaeger":
+		if strings.HasSuffix(path, "/api/traces") {
+			return fmt.Errorf("%s='%s': jaeger endpoint must not include /api/traces, it is appended automatically",
+				source, endpoint)
+		}
+	}
+
+	return nil
+}
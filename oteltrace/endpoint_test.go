@@ -0,0 +1,46 @@
+package oteltrace
+
+import (
+	"testing"
+)
+
+func TestValidateEndpoint(t *testing.T) {
+	table := []struct {
+		name     string
+		exporter string
+		endpoint string
+		signal   bool
+		valid    bool
+	}{
+		{"empty", "grpc", "", false, true},
+		{"stdout ignores endpoint", "stdout", "garbage", false, true},
+		{"grpc", "grpc", "http://collector:4317", false, true},
+		{"grpc default exporter", "", "https://collector:4317", false, true},
+		{"grpc trailing slash", "grpc", "http://collector:4317/", false, true},
+		{"grpc missing scheme", "grpc", "collector:4317", false, false},
+		{"grpc bad scheme", "grpc", "tcp://collector:4317", false, false},
+		{"grpc missing host", "grpc", "http://:4317", false, false},
+		{"grpc bad port", "grpc", "http://collector:port", false, false},
+		{"grpc port out of range", "grpc", "http://collector:65536", false, false},
+		{"grpc path", "grpc", "http://collector:4317/v1/traces", false, false},
+		{"grpc query", "grpc", "http://collector:4317?x=1", false, false},
+		{"http", "http", "http://collector:4318", false, true},
+		{"http base path", "http", "http://collector:4318/otlp", false, true},
+		{"http base with signal path", "http", "http://collector:4318/v1/traces", false, false},
+		{"http signal full url", "http", "http://collector:4318/v1/traces", true, true},
+		{"jaeger", "jaeger", "http://jaeger:14268", false, true},
+		{"jaeger api path", "jaeger", "http://jaeger:14268/api/traces", false, false},
+	}
+
+	for _, data := range table {
+		t.Run(data.name, func(t *testing.T) {
+			err := validateEndpoint(data.exporter, "test", data.endpoint, data.signal)
+			if data.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !data.valid && err == nil {
+				t.Errorf("expected error for endpoint '%s'", data.endpoint)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package oteltrace
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// pipelineStats holds telemetry pipeline health published in expvar
+// as map "otelconfig":
+//
+//	queue_depth       - spans queued for export, not yet exported
+//	queued_spans      - sampled spans accepted by the export processor
+//	dropped_spans     - sampled spans dropped by a full queue
+//	exported_spans    - spans exported successfully
+//	failed_spans      - spans in failed exports
+//	export_failures   - failed export calls
+//	export_latency_ms - latency of last export call
+//
+// The batch processor does not expose its queue, so queue_depth and
+// dropped_spans are approximate: spans are dropped only by the batch
+// processor, and counted as dropped when queue_depth reached the queue
+// size from OTEL_BSP_MAX_QUEUE_SIZE plus the batch being exported.
+type pipelineStats struct {
+	vars    *expvar.Map
+	latency *expvar.Float
+	depth   *atomic.Int64
+}
+
+var (
+	statsOnce sync.Once
+	stats     pipelineStats
+)
+
+// expvarStats publishes the stats only once, since expvar panics on
+// duplicate names.
+func expvarStats() pipelineStats {
+	statsOnce.Do(func() {
+		stats.vars = expvar.NewMap("otelconfig")
+		stats.latency = new(expvar.Float)
+		stats.vars.Set("export_latency_ms", stats.latency)
+		stats.depth = new(atomic.Int64)
+		stats.vars.Set("queue_depth", expvar.Func(func() any { return max(stats.depth.Load(), 0) }))
+		for _, name := range []string{"queued_spans", "dropped_spans", "exported_spans", "failed_spans", "export_failures"} {
+			stats.vars.Add(name, 0)
+		}
+	})
+	return stats
+}
+
+// expvarEnabled tells whether expvar stats are enabled by
+// options or by OTELCONFIG_EXPVAR.
+func expvarEnabled(options TraceOptions) bool {
+	return options.Expvar || getEnvBool("expvarEnabled", "OTELCONFIG_EXPVAR", options.Debug)
+}
+
+// statsExporter records export stats.
+type statsExporter struct {
+	tracesdk.SpanExporter
+	stats pipelineStats
+}
+
+// ExportSpans implements tracesdk.SpanExporter.
+func (e statsExporter) ExportSpans(ctx context.Context, spans []tracesdk.ReadOnlySpan) error {
+	begin := time.Now()
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	e.stats.latency.Set(float64(time.Since(begin).Microseconds()) / 1000)
+
+	n := int64(len(spans))
+	e.stats.depth.Add(-n)
+	if err != nil {
+		e.stats.vars.Add("export_failures", 1)
+		e.stats.vars.Add("failed_spans", n)
+	} else {
+		e.stats.vars.Add("exported_spans", n)
+	}
+
+	return err
+}
+
+// statsProcessor counts spans handed to the wrapped export processor.
+// It never drops spans itself: a span is counted as dropped when
+// capacity spans already wait for export, since the batch processor
+// then has no room for it. Zero capacity means unbounded.
+type statsProcessor struct {
+	tracesdk.SpanProcessor
+	stats    pipelineStats
+	capacity int64
+	stopped  atomic.Bool
+}
+
+// OnEnd implements tracesdk.SpanProcessor.
+func (p *statsProcessor) OnEnd(s tracesdk.ReadOnlySpan) {
+	p.SpanProcessor.OnEnd(s)
+
+	if !s.SpanContext().IsSampled() || p.stopped.Load() {
+		return // not queued by the wrapped processor
+	}
+	if p.capacity > 0 && p.stats.depth.Load() >= p.capacity {
+		p.stats.vars.Add("dropped_spans", 1)
+		return
+	}
+	p.stats.depth.Add(1)
+	p.stats.vars.Add("queued_spans", 1)
+}
+
+// Shutdown implements tracesdk.SpanProcessor.
+func (p *statsProcessor) Shutdown(ctx context.Context) error {
+	p.stopped.Store(true) // the wrapped processor drops spans ended from now
+	return p.SpanProcessor.Shutdown(ctx)
+}
+
+// batchCapacity resolves how many spans the batch processor holds, like
+// the SDK does from OTEL_BSP_MAX_QUEUE_SIZE and
+// OTEL_BSP_MAX_EXPORT_BATCH_SIZE: the queue plus the batch being
+// exported.
+func batchCapacity(debug bool) int64 {
+	const me = "batchCapacity"
+
+	size := func(name string, def int64) int64 {
+		str := getEnv(me, name, debug)
+		if str == "" {
+			return def
+		}
+		v, err := strconv.ParseInt(str, 10, 64)
+		if err != nil || v < 1 {
+			log.Printf("%s: bad %s='%s'", me, name, str)
+			return def
+		}
+		return v
+	}
+
+	queue := size("OTEL_BSP_MAX_QUEUE_SIZE", tracesdk.DefaultMaxQueueSize)
+	batch := size("OTEL_BSP_MAX_EXPORT_BATCH_SIZE", tracesdk.DefaultMaxExportBatchSize)
+
+	return queue + min(batch, queue)
+}
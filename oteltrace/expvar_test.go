@@ -0,0 +1,113 @@
+package oteltrace
+
+import (
+	"context"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestExpvarDestinations(t *testing.T) {
+	keepGlobals(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+
+	options := TraceOptions{
+		Exporter: "http",
+		Endpoint: server.URL,
+		Expvar:   true,
+		Destinations: []Destination{
+			{Name: "vendor1", Exporter: "http", Endpoint: server.URL},
+			{Name: "vendor2", Exporter: "http", Endpoint: server.URL},
+		},
+	}
+
+	vars := expvarStats().vars
+	queued := vars.Get("queued_spans").(*expvar.Int).Value()
+	exported := vars.Get("exported_spans").(*expvar.Int).Value()
+
+	tracer, cancel, err := TraceStart(options)
+	if err != nil {
+		t.Fatalf("trace start: %v", err)
+	}
+	defer cancel()
+
+	_, span := tracer.Start(context.Background(), "work")
+	span.End()
+
+	if err := ForceFlush(context.Background()); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	if got := vars.Get("queued_spans").(*expvar.Int).Value() - queued; got != 1 {
+		t.Errorf("queued_spans: got %d, want 1", got)
+	}
+	if got := vars.Get("exported_spans").(*expvar.Int).Value() - exported; got != 1 {
+		t.Errorf("exported_spans: got %d, want 1", got)
+	}
+}
+
+// blockingExporter holds exports until release is closed.
+// Each export is announced on started.
+type blockingExporter struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (e blockingExporter) ExportSpans(context.Context, []tracesdk.ReadOnlySpan) error {
+	e.started <- struct{}{}
+	<-e.release
+	return nil
+}
+
+func (e blockingExporter) Shutdown(context.Context) error { return nil }
+
+func TestExpvarQueueDepth(t *testing.T) {
+	stats := expvarStats()
+	depth := stats.depth.Load()
+	dropped := stats.vars.Get("dropped_spans").(*expvar.Int).Value()
+	exported := stats.vars.Get("exported_spans").(*expvar.Int).Value()
+
+	exp := blockingExporter{started: make(chan struct{}, 10), release: make(chan struct{})}
+	batcher := tracesdk.NewBatchSpanProcessor(statsExporter{SpanExporter: exp, stats: stats},
+		tracesdk.WithMaxQueueSize(2), tracesdk.WithMaxExportBatchSize(1))
+	proc := &statsProcessor{SpanProcessor: batcher, stats: stats, capacity: 3}
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(proc))
+
+	end := func() {
+		_, span := tp.Tracer("test").Start(context.Background(), "work")
+		span.End()
+	}
+
+	end()
+	<-exp.started // first span taken from the queue, export blocked
+
+	for range 4 {
+		end() // two spans queued, two dropped by the batch processor
+	}
+
+	if got := stats.depth.Load() - depth; got != 3 {
+		t.Errorf("queue_depth: got %d, want 3", got)
+	}
+	if got := stats.vars.Get("dropped_spans").(*expvar.Int).Value() - dropped; got != 2 {
+		t.Errorf("dropped_spans: got %d, want 2", got)
+	}
+
+	close(exp.release)
+	if err := tp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	if got := stats.depth.Load() - depth; got != 0 {
+		t.Errorf("queue_depth after flush: got %d, want 0", got)
+	}
+	if got := stats.vars.Get("exported_spans").(*expvar.Int).Value() - exported; got != 3 {
+		t.Errorf("exported_spans: got %d, want 3", got)
+	}
+}
@@ -0,0 +1,55 @@
+package oteltrace
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// GlobalPolicy defines how TraceStart behaves when a real SDK
+// TracerProvider is already installed as the global provider,
+// for instance by the host application embedding a library.
+type GlobalPolicy int
+
+const (
+	// GlobalReplace installs a new provider over the existing one.
+	// This is the default.
+	GlobalReplace GlobalPolicy = iota
+
+	// GlobalReuse keeps the existing provider and propagator, and returns
+	// a tracer from the existing provider. No exporter is created.
+	GlobalReuse
+
+	// GlobalError makes TraceStart fail with ErrProviderInstalled.
+	GlobalError
+)
+
+// ErrProviderInstalled is returned by TraceStart under GlobalError policy
+// when a real SDK TracerProvider is already installed as global.
+var ErrProviderInstalled = errors.New("global TracerProvider already installed")
+
+// installedProvider returns the global SDK TracerProvider, if any.
+// The wrapped provider is returned as the second value.
+func installedProvider() (trace.TracerProvider, *tracesdk.TracerProvider) {
+	tp := otel.GetTracerProvider()
+	if sdk := sdkProvider(tp); sdk != nil {
+		return tp, sdk
+	}
+	return nil, nil
+}
+
+// sdkProvider unwraps tp down to the SDK TracerProvider.
+// It returns nil if tp is not backed by the SDK.
+func sdkProvider(tp trace.TracerProvider) *tracesdk.TracerProvider {
+	switch p := tp.(type) {
+	case *tracesdk.TracerProvider:
+		return p
+	case profilingProvider:
+		return sdkProvider(p.TracerProvider)
+	case clockProvider:
+		return sdkProvider(p.TracerProvider)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package oteltrace
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+// keepGlobals restores the global tracer provider and propagator, and the
+// provider and report recorded by TraceStart, when the test finishes.
+func keepGlobals(t *testing.T) {
+	t.Helper()
+
+	tp := otel.GetTracerProvider()
+	prop := otel.GetTextMapPropagator()
+
+	providerLock.Lock()
+	savedProvider, savedInstalled, savedReport := provider, installed, report
+	providerLock.Unlock()
+
+	t.Cleanup(func() {
+		// setting the current value again is reported as error by otel
+		if otel.GetTracerProvider() != tp {
+			otel.SetTracerProvider(tp)
+		}
+		if otel.GetTextMapPropagator() != prop {
+			otel.SetTextMapPropagator(prop)
+		}
+
+		providerLock.Lock()
+		provider, installed, report = savedProvider, savedInstalled, savedReport
+		providerLock.Unlock()
+	})
+}
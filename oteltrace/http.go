@@ -0,0 +1,115 @@
+package oteltrace
+
+import (
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HTTPFilter tells whether a request should be traced.
+// It is evaluated before span creation, so filtered out requests
+// never produce spans.
+//
+// HTTPFilter is compatible with filter options from contrib
+// instrumentations:
+//
+//	otelhttp.WithFilter(oteltrace.SkipPaths("/health"))
+//	otelgin.WithFilter(oteltrace.SkipPaths("/health"))
+//
+//	filter := oteltrace.SkipPaths("/health")
+//	otelecho.WithSkipper(func(c echo.Context) bool { return !filter(c.Request()) })
+type HTTPFilter = func(*http.Request) bool
+
+// SkipPaths returns a filter that skips requests for the given paths,
+// like probes and static assets. A path ending with * matches any
+// path with that prefix:
+//
+//	oteltrace.SkipPaths("/health", "/ready", "/static/*")
+func SkipPaths(paths ...string) HTTPFilter {
+	return func(r *http.Request) bool {
+		for _, p := range paths {
+			if prefix, found := strings.CutSuffix(p, "*"); found {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					return false
+				}
+				continue
+			}
+			if r.URL.Path == p {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// HTTPMiddleware returns a net/http middleware creating a server span
+// for every request accepted by all filters, with otelhttp.NewHandler,
+// so spans and metrics follow the contrib instrumentation semantics.
+// The trace context is extracted from request headers with the global
+// propagator.
+//
+// When the request is routed by http.ServeMux, the span is named after
+// the matched pattern, like "GET /items/{id}", and records http.route.
+// Otherwise the span is named operation.
+//
+// The signature fits chi router.Use:
+//
+//	router.Use(oteltrace.HTTPMiddleware("my-service", oteltrace.SkipPaths("/health")))
+func HTTPMiddleware(operation string, filters ...HTTPFilter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return HTTPHandler(next, operation, filters...)
+	}
+}
+
+// HTTPHandler wraps handler with a server span for every request
+// accepted by all filters. See HTTPMiddleware.
+func HTTPHandler(handler http.Handler, operation string, filters ...HTTPFilter) http.Handler {
+	var options []otelhttp.Option
+	for _, f := range filters {
+		options = append(options, otelhttp.WithFilter(f))
+	}
+	return otelhttp.NewHandler(routeHandler(handler), operation, options...)
+}
+
+// routeHandler names the server span after the http.ServeMux pattern
+// matched by handler, if any.
+func routeHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+
+		// http.ServeMux records the matched pattern in the request
+		if r.Pattern == "" {
+			return
+		}
+
+		route := semconv.HTTPRouteKey.String(routePath(r.Pattern))
+
+		span := trace.SpanFromContext(r.Context())
+		span.SetName(routeSpanName(r))
+		span.SetAttributes(route)
+
+		if labeler, found := otelhttp.LabelerFromContext(r.Context()); found {
+			labeler.Add(route) // metrics
+		}
+	})
+}
+
+// routeSpanName names the span after the request method and route.
+func routeSpanName(r *http.Request) string {
+	return r.Method + " " + routePath(r.Pattern)
+}
+
+// routePath removes method and host from a ServeMux pattern
+// like "GET example.com/items/{id}".
+func routePath(pattern string) string {
+	if _, path, found := strings.Cut(pattern, " "); found {
+		pattern = strings.TrimSpace(path)
+	}
+	if i := strings.Index(pattern, "/"); i > 0 {
+		pattern = pattern[i:]
+	}
+	return pattern
+}
@@ -0,0 +1,86 @@
+package oteltrace
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	keepGlobals(t)
+
+	rec := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(rec)))
+
+	var flusher, hijacker bool
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		_, flusher = w.(http.Flusher)
+		_, hijacker = w.(http.Hijacker)
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	server := httptest.NewServer(HTTPHandler(mux, "operation", SkipPaths("/health")))
+	defer server.Close()
+
+	table := []struct {
+		path     string
+		spanName string // empty means no span
+		route    string
+	}{
+		{"/items/1", "GET /items/{id}", "/items/{id}"},
+		{"/unknown", "operation", ""},
+		{"/health", "", ""},
+	}
+
+	for _, data := range table {
+		t.Run(data.path, func(t *testing.T) {
+			before := len(rec.Ended())
+
+			resp, err := http.Get(server.URL + data.path)
+			if err != nil {
+				t.Fatalf("get: %v", err)
+			}
+			resp.Body.Close()
+
+			spans := rec.Ended()[before:]
+
+			if data.spanName == "" {
+				if len(spans) != 0 {
+					t.Fatalf("unexpected span: %s", spans[0].Name())
+				}
+				return
+			}
+
+			if len(spans) != 1 {
+				t.Fatalf("expected 1 span, got %d", len(spans))
+			}
+			if got := spans[0].Name(); got != data.spanName {
+				t.Errorf("span name: got '%s', want '%s'", got, data.spanName)
+			}
+			if got := spans[0].SpanKind(); got != trace.SpanKindServer {
+				t.Errorf("span kind: got %v, want server", got)
+			}
+
+			var route string
+			for _, a := range spans[0].Attributes() {
+				if a.Key == "http.route" {
+					route = a.Value.AsString()
+				}
+			}
+			if route != data.route {
+				t.Errorf("http.route: got '%s', want '%s'", route, data.route)
+			}
+		})
+	}
+
+	if !flusher || !hijacker {
+		t.Errorf("response writer lost interfaces: flusher=%t hijacker=%t", flusher, hijacker)
+	}
+}
@@ -0,0 +1,121 @@
+package oteltrace
+
+import (
+	"context"
+	"log"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// endpointVar matches $(VAR) references in endpoint templates,
+// the same syntax Kubernetes uses for dependent env vars.
+var endpointVar = regexp.MustCompile(`\$\(([A-Za-z_][A-Za-z0-9_]*)\)`)
+
+// lookupHost resolves collector service names. Tests replace it.
+var lookupHost = net.DefaultResolver.LookupHost
+
+// expandEndpoint resolves $(VAR) references from env vars, like
+// http://$(NODE_IP):4317 with NODE_IP from the downward API:
+//
+//	env:
+//	- name: NODE_IP
+//	  valueFrom:
+//	    fieldRef:
+//	      fieldPath: status.hostIP
+//
+// It returns whether any reference was found.
+func expandEndpoint(endpoint string, debug bool) (string, bool) {
+	const me = "expandEndpoint"
+
+	if !endpointVar.MatchString(endpoint) {
+		return endpoint, false
+	}
+
+	expanded := endpointVar.ReplaceAllStringFunc(endpoint, func(ref string) string {
+		name := endpointVar.FindStringSubmatch(ref)[1]
+		value := getEnv(me, name, debug)
+		if value == "" {
+			log.Printf("%s: endpoint '%s' references empty env var %s", me, endpoint, name)
+		}
+		return value
+	})
+
+	if debug {
+		log.Printf("%s: endpoint '%s' expanded to '%s'", me, endpoint, expanded)
+	}
+
+	return expanded, true
+}
+
+// discoverCollector looks for the collector Kubernetes service, usually
+// a DaemonSet service with internalTrafficPolicy=Local, so that spans
+// are sent to the node-local agent. It first tries the service env vars
+// injected by Kubernetes, then the service DNS name.
+//
+// A namespace-qualified service, like otel-collector.monitoring, is
+// resolved by DNS as given, since Kubernetes injects service env vars
+// only for the pod namespace.
+//
+// Only OTLP exporters are discovered: the jaeger exporter does not speak
+// OTLP and the stdout exporter has no endpoint.
+// It returns empty string when the collector is not found.
+func discoverCollector(service, exporter string, debug bool) string {
+	const me = "discoverCollector"
+
+	port := "4317"
+	portName := "OTLP"
+	switch exporter {
+	case "", "grpc":
+	case "http":
+		port = "4318"
+		portName = "OTLP_HTTP"
+	default:
+		if debug {
+			log.Printf("%s: no discovery for exporter '%s'", me, exporter)
+		}
+		return ""
+	}
+
+	host := service
+
+	if !strings.Contains(service, ".") {
+		prefix := strings.ToUpper(strings.ReplaceAll(service, "-", "_")) + "_SERVICE_"
+
+		if envHost := getEnv(me, prefix+"HOST", debug); envHost != "" {
+			if p := getEnv(me, prefix+"PORT_"+portName, debug); p != "" {
+				port = p
+			}
+			return "http://" + net.JoinHostPort(envHost, port)
+		}
+
+		if ns := podNamespace(debug); ns != "" {
+			host = service + "." + ns + ".svc"
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if _, err := lookupHost(ctx, host); err != nil {
+		log.Printf("%s: collector service not found: %v", me, err)
+		return ""
+	}
+
+	return "http://" + net.JoinHostPort(host, port)
+}
+
+// podNamespace finds the current Kubernetes namespace.
+func podNamespace(debug bool) string {
+	const me = "podNamespace"
+	if ns := getEnv(me, "POD_NAMESPACE", debug); ns != "" {
+		return ns
+	}
+	data, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
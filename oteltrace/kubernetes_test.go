@@ -0,0 +1,85 @@
+package oteltrace
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// fakeLookup replaces lookupHost with a resolver knowing only hosts.
+// It returns the list of looked up names.
+func fakeLookup(t *testing.T, hosts ...string) *[]string {
+	t.Helper()
+	var lookups []string
+	saved := lookupHost
+	lookupHost = func(_ context.Context, host string) ([]string, error) {
+		lookups = append(lookups, host)
+		if slices.Contains(hosts, host) {
+			return []string{"10.0.0.3"}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	t.Cleanup(func() { lookupHost = saved })
+	return &lookups
+}
+
+func TestDiscoverCollector(t *testing.T) {
+	t.Setenv("OTEL_COLLECTOR_SERVICE_HOST", "10.0.0.1")
+	t.Setenv("OTEL_COLLECTOR_SERVICE_PORT_OTLP", "14317")
+	t.Setenv("OTEL_COLLECTOR_SERVICE_PORT_OTLP_HTTP", "")
+	t.Setenv("OTEL_COLLECTOR.MONITORING_SERVICE_HOST", "10.0.0.2")
+	t.Setenv("AGENT_SERVICE_HOST", "")
+	t.Setenv("POD_NAMESPACE", "obs")
+
+	fakeLookup(t, "otel-collector.monitoring", "agent.obs.svc")
+
+	table := []struct {
+		name     string
+		service  string
+		exporter string
+		want     string
+	}{
+		{"grpc from env", "otel-collector", "grpc", "http://10.0.0.1:14317"},
+		{"default exporter from env", "otel-collector", "", "http://10.0.0.1:14317"},
+		{"http default port", "otel-collector", "http", "http://10.0.0.1:4318"},
+		{"jaeger skipped", "otel-collector", "jaeger", ""},
+		{"stdout skipped", "otel-collector", "stdout", ""},
+		{"namespaced resolved by dns only", "otel-collector.monitoring", "grpc", "http://otel-collector.monitoring:4317"},
+		{"namespaced not found", "otel-collector.tracing", "grpc", ""},
+		{"pod namespace by dns", "agent", "http", "http://agent.obs.svc:4318"},
+	}
+
+	for _, data := range table {
+		t.Run(data.name, func(t *testing.T) {
+			if got := discoverCollector(data.service, data.exporter, false); got != data.want {
+				t.Errorf("endpoint: got '%s', want '%s'", got, data.want)
+			}
+		})
+	}
+}
+
+func TestDiscoverCollectorNoop(t *testing.T) {
+	keepGlobals(t)
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	t.Setenv("OTEL_COLLECTOR_SERVICE_HOST", "")
+
+	lookups := fakeLookup(t)
+
+	_, cancel, err := TraceStart(TraceOptions{
+		DefaultService:     "test",
+		NoopTracerProvider: true,
+		DiscoverCollector:  true,
+		CollectorService:   "otel-collector",
+	})
+	if err != nil {
+		t.Fatalf("trace start: %v", err)
+	}
+	cancel()
+
+	if len(*lookups) != 0 {
+		t.Errorf("lookups: got %v, want none", *lookups)
+	}
+}
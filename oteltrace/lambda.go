@@ -0,0 +1,47 @@
+package oteltrace
+
+import (
+	"log"
+	"os"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// inLambda detects the AWS Lambda execution environment.
+func inLambda(debug bool) bool {
+	const me = "inLambda"
+	return getEnv(me, "AWS_LAMBDA_FUNCTION_NAME", debug) != ""
+}
+
+// lambdaAttributes returns resource attributes describing the Lambda function.
+func lambdaAttributes() []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.CloudProviderAWS,
+		semconv.CloudPlatformAWSLambda,
+		semconv.FaaSNameKey.String(os.Getenv("AWS_LAMBDA_FUNCTION_NAME")),
+	}
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		attrs = append(attrs, semconv.CloudRegionKey.String(region))
+	}
+	if version := os.Getenv("AWS_LAMBDA_FUNCTION_VERSION"); version != "" {
+		attrs = append(attrs, semconv.FaaSVersionKey.String(version))
+	}
+	if stream := os.Getenv("AWS_LAMBDA_LOG_STREAM_NAME"); stream != "" {
+		attrs = append(attrs, semconv.FaaSInstanceKey.String(stream))
+	}
+	return attrs
+}
+
+// lambdaMode tells whether Lambda-friendly mode is active.
+func lambdaMode(options TraceOptions) bool {
+	const me = "lambdaMode"
+	if !options.Lambda {
+		return false
+	}
+	active := inLambda(options.Debug)
+	if options.Debug {
+		log.Printf("%s: lambda environment detected: %t", me, active)
+	}
+	return active
+}
@@ -0,0 +1,49 @@
+package oteltrace
+
+import (
+	"log"
+	"strings"
+
+	"github.com/go-logr/logr/funcr"
+	"go.opentelemetry.io/otel"
+)
+
+// sdkLogging routes the SDK internal logger to the standard log package,
+// so exporter retries and dropped data warnings show up in application logs.
+//
+// OTEL_LOG_LEVEL selects the verbosity: error, warn, info, debug.
+// When OTEL_LOG_LEVEL is unset, the SDK logger is left untouched.
+func sdkLogging(debug bool) {
+	const me = "sdkLogging"
+
+	level := strings.ToLower(strings.TrimSpace(getEnv(me, "OTEL_LOG_LEVEL", debug)))
+	if level == "" {
+		return
+	}
+
+	// verbosity levels expected by otel.SetLogger
+	var verbosity int
+	switch level {
+	case "error":
+		verbosity = 0
+	case "warn":
+		verbosity = 1
+	case "info":
+		verbosity = 4
+	case "debug":
+		verbosity = 8
+	default:
+		log.Printf("%s: unrecognized OTEL_LOG_LEVEL='%s', using info", me, level)
+		verbosity = 4
+	}
+
+	logger := funcr.New(func(prefix, args string) {
+		if prefix == "" {
+			log.Printf("otel: %s", args)
+			return
+		}
+		log.Printf("otel: %s: %s", prefix, args)
+	}, funcr.Options{Verbosity: verbosity})
+
+	otel.SetLogger(logger)
+}
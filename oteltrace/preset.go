@@ -0,0 +1,281 @@
+package oteltrace
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+var (
+	presetLock sync.Mutex
+	presets    = map[string]func(*TraceOptions) error{
+		"datadog": presetNoError(PresetDatadog),
+		"elastic": presetNoError(PresetElastic),
+		"uptrace": presetUptrace,
+	}
+)
+
+// presetNoError adapts a preset that cannot fail.
+func presetNoError(preset func(*TraceOptions)) func(*TraceOptions) error {
+	return func(options *TraceOptions) error {
+		preset(options)
+		return nil
+	}
+}
+
+// RegisterPreset makes a preset available by name to TraceOptions.Preset
+// and OTELCONFIG_PRESET. Registering an existing name replaces it.
+// Built-in presets are: datadog, elastic, uptrace.
+func RegisterPreset(name string, preset func(*TraceOptions)) {
+	presetLock.Lock()
+	presets[name] = presetNoError(preset)
+	presetLock.Unlock()
+}
+
+// applyPreset applies the named preset to options.
+func applyPreset(name string, options *TraceOptions) error {
+	presetLock.Lock()
+	preset, found := presets[name]
+	presetLock.Unlock()
+	if !found {
+		return fmt.Errorf("unknown preset '%s', registered presets: %v",
+			name, presetNames())
+	}
+	if err := preset(options); err != nil {
+		return fmt.Errorf("preset '%s': %w", name, err)
+	}
+	return nil
+}
+
+func presetNames() []string {
+	presetLock.Lock()
+	defer presetLock.Unlock()
+	var names []string
+	for n := range presets {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PresetDatadog configures options to export traces to the local Datadog
+// Agent OTLP intake. Call it before TraceStart:
+//
+//	options := oteltrace.TraceOptions{DefaultService: "my-program"}
+//	oteltrace.PresetDatadog(&options)
+//	tracer, cancel, err := oteltrace.TraceStart(options)
+//
+// The agent host is taken from DD_AGENT_HOST (default localhost).
+// Datadog unified service tags are mapped to resource attributes:
+//
+//	DD_ENV     -> deployment.environment
+//	DD_SERVICE -> service.name (as DefaultService)
+//	DD_VERSION -> service.version
+//
+// Fields already set in options are kept.
+func PresetDatadog(options *TraceOptions) {
+	if options.Exporter == "" {
+		options.Exporter = "grpc"
+	}
+
+	if options.Endpoint == "" {
+		host := os.Getenv("DD_AGENT_HOST")
+		if host == "" {
+			host = "localhost"
+		}
+		port := "4317" // Agent OTLP gRPC receiver
+		if presetExporter(options) == "http" {
+			port = "4318" // Agent OTLP HTTP receiver
+		}
+		options.Endpoint = "http://" + net.JoinHostPort(host, port)
+	}
+
+	if svc := os.Getenv("DD_SERVICE"); svc != "" && options.DefaultService == "" {
+		options.DefaultService = svc
+	}
+
+	options.ResourceAttributes = appendEnvAttr(options.ResourceAttributes,
+		semconv.DeploymentEnvironmentKey, "DD_ENV")
+	options.ResourceAttributes = appendEnvAttr(options.ResourceAttributes,
+		semconv.ServiceVersionKey, "DD_VERSION")
+}
+
+// presetExporter resolves the exporter like TraceStart does, including
+// OTELCONFIG_EXPORTER and OTEL_EXPORTER_OTLP_*PROTOCOL, so that presets
+// pick the port for the exporter actually used.
+func presetExporter(options *TraceOptions) string {
+	exporter, err := selectExporter(*options)
+	if err != nil {
+		return options.Exporter // reported by TraceStart
+	}
+	return exporter
+}
+
+// appendEnvAttr appends key=$env to attrs, unless env is empty or
+// attrs already holds key.
+func appendEnvAttr(attrs []attribute.KeyValue, key attribute.Key, env string) []attribute.KeyValue {
+	value := os.Getenv(env)
+	if value == "" {
+		return attrs
+	}
+	for _, a := range attrs {
+		if a.Key == key {
+			return attrs
+		}
+	}
+	return append(attrs, key.String(value))
+}
+
+// PresetElastic configures options to export traces to the Elastic APM
+// Server OTLP intake. Call it before TraceStart.
+//
+// The server is taken from ELASTIC_APM_SERVER_URL (default
+// http://localhost:8200), which accepts both OTLP/gRPC and OTLP/HTTP.
+// Credentials are sent in the Authorization header:
+//
+//	ELASTIC_APM_SECRET_TOKEN -> Authorization: Bearer <token>
+//	ELASTIC_APM_API_KEY      -> Authorization: ApiKey <key>
+//
+// Service metadata is mapped to resource attributes:
+//
+//	ELASTIC_APM_SERVICE_NAME    -> service.name (as DefaultService)
+//	ELASTIC_APM_ENVIRONMENT     -> deployment.environment
+//	ELASTIC_APM_SERVICE_VERSION -> service.version
+//
+// Fields already set in options are kept.
+func PresetElastic(options *TraceOptions) {
+	if options.Exporter == "" {
+		options.Exporter = "grpc"
+	}
+
+	if options.Endpoint == "" {
+		options.Endpoint = os.Getenv("ELASTIC_APM_SERVER_URL")
+		if options.Endpoint == "" {
+			options.Endpoint = "http://localhost:8200"
+		}
+	}
+
+	var auth string
+	if token := os.Getenv("ELASTIC_APM_SECRET_TOKEN"); token != "" {
+		auth = "Bearer " + token
+	}
+	if key := os.Getenv("ELASTIC_APM_API_KEY"); key != "" {
+		auth = "ApiKey " + key // API key wins over secret token, like the Elastic agents
+	}
+	if auth != "" {
+		options.Headers = setHeader(options.Headers, "Authorization", auth)
+	}
+
+	if svc := os.Getenv("ELASTIC_APM_SERVICE_NAME"); svc != "" && options.DefaultService == "" {
+		options.DefaultService = svc
+	}
+
+	options.ResourceAttributes = appendEnvAttr(options.ResourceAttributes,
+		semconv.DeploymentEnvironmentKey, "ELASTIC_APM_ENVIRONMENT")
+	options.ResourceAttributes = appendEnvAttr(options.ResourceAttributes,
+		semconv.ServiceVersionKey, "ELASTIC_APM_SERVICE_VERSION")
+}
+
+// setHeader sets headers[key]=value, unless headers already holds key.
+// It allocates the map if needed.
+func setHeader(headers map[string]string, key, value string) map[string]string {
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	if _, found := headers[key]; !found {
+		headers[key] = value
+	}
+	return headers
+}
+
+// PresetUptrace configures options to export traces to Uptrace, using the
+// DSN from UPTRACE_DSN, the same way the native uptrace-go SDK does:
+//
+//	export UPTRACE_DSN=https://<token>@api.uptrace.dev?grpc=4317
+//
+// The endpoint is derived from the DSN host and ports, and the DSN itself
+// is sent in the uptrace-dsn header. A missing or invalid DSN is logged
+// and options are left untouched. When the preset is selected by name
+// (TraceOptions.Preset or OTELCONFIG_PRESET), TraceStart fails instead.
+//
+// Fields already set in options are kept.
+func PresetUptrace(options *TraceOptions) {
+	const me = "PresetUptrace"
+	if err := presetUptrace(options); err != nil {
+		log.Printf("%s: %v", me, err)
+	}
+}
+
+// presetUptrace implements PresetUptrace, reporting a missing or
+// invalid DSN.
+func presetUptrace(options *TraceOptions) error {
+	dsn := os.Getenv("UPTRACE_DSN")
+	if dsn == "" {
+		return fmt.Errorf("missing UPTRACE_DSN")
+	}
+
+	endpoint, err := uptraceEndpoint(dsn, presetExporter(options))
+	if err != nil {
+		return err
+	}
+
+	if options.Exporter == "" {
+		options.Exporter = "grpc"
+	}
+
+	if options.Endpoint == "" {
+		options.Endpoint = endpoint
+	}
+
+	options.Headers = setHeader(options.Headers, "uptrace-dsn", dsn)
+
+	return nil
+}
+
+// uptraceEndpoint derives the OTLP endpoint from an Uptrace DSN.
+func uptraceEndpoint(dsn, exporter string) (string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", fmt.Errorf("bad UPTRACE_DSN: %w", err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+	default:
+		return "", fmt.Errorf("bad UPTRACE_DSN: unsupported scheme '%s'", u.Scheme)
+	}
+
+	if u.User == nil || u.User.Username() == "" {
+		return "", fmt.Errorf("bad UPTRACE_DSN: missing token")
+	}
+
+	host := u.Hostname()
+	if host == "" {
+		return "", fmt.Errorf("bad UPTRACE_DSN: missing host")
+	}
+
+	// Uptrace cloud receives OTLP on a dedicated host.
+	if host == "uptrace.dev" || host == "api.uptrace.dev" {
+		if exporter == "http" {
+			return "https://otlp.uptrace.dev", nil
+		}
+		return "https://otlp.uptrace.dev:4317", nil
+	}
+
+	port := u.Port()
+	if grpcPort := u.Query().Get("grpc"); grpcPort != "" && exporter != "http" {
+		port = grpcPort
+	}
+	if port == "" {
+		return u.Scheme + "://" + host, nil
+	}
+
+	return u.Scheme + "://" + net.JoinHostPort(host, port), nil
+}
@@ -0,0 +1,41 @@
+package oteltrace
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestPresetHeadersWithEnv(t *testing.T) {
+	t.Setenv("ELASTIC_APM_SECRET_TOKEN", "secret")
+	t.Setenv("ELASTIC_APM_API_KEY", "")
+
+	table := []struct {
+		name          string
+		envHeaders    string
+		envTraces     string
+		expectHeaders map[string]string
+	}{
+		{"preset only", "", "", map[string]string{"Authorization": "Bearer secret"}},
+		{"env adds", "x-tenant=acme", "", map[string]string{"Authorization": "Bearer secret", "x-tenant": "acme"}},
+		{"env overrides", "authorization=Basic%20xyz", "", map[string]string{"authorization": "Basic xyz"}},
+		{"traces env wins", "x-tenant=acme", "x-team=core", map[string]string{"Authorization": "Bearer secret", "x-team": "core"}},
+		{"bad pair ignored", "garbage,x-tenant=acme", "", map[string]string{"Authorization": "Bearer secret", "x-tenant": "acme"}},
+	}
+
+	for _, data := range table {
+		t.Run(data.name, func(t *testing.T) {
+			t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", data.envHeaders)
+			t.Setenv("OTEL_EXPORTER_OTLP_TRACES_HEADERS", data.envTraces)
+
+			var options TraceOptions
+			if err := applyPreset("elastic", &options); err != nil {
+				t.Fatalf("preset: %v", err)
+			}
+
+			headers := headersWithEnv(options.Headers)
+			if !maps.Equal(headers, data.expectHeaders) {
+				t.Errorf("headers: got %v, want %v", headers, data.expectHeaders)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package oteltrace
+
+import (
+	"log"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// SpanTransform is invoked for each finished span before export.
+// It returns the attributes to export in place of span.Attributes(),
+// and whether the span should be exported at all.
+//
+//	options.OnEnd = func(span tracesdk.ReadOnlySpan) ([]attribute.KeyValue, bool) {
+//		if span.Name() == "healthcheck" {
+//			return nil, false // drop
+//		}
+//		var attrs []attribute.KeyValue
+//		for _, a := range span.Attributes() {
+//			if a.Key != "user.email" {
+//				attrs = append(attrs, a)
+//			}
+//		}
+//		return attrs, true
+//	}
+type SpanTransform func(span tracesdk.ReadOnlySpan) (attributes []attribute.KeyValue, keep bool)
+
+// transformProcessor applies SpanTransform before the wrapped export processor.
+type transformProcessor struct {
+	tracesdk.SpanProcessor
+	transform SpanTransform
+}
+
+// OnEnd implements tracesdk.SpanProcessor.
+func (p transformProcessor) OnEnd(s tracesdk.ReadOnlySpan) {
+	attrs, keep := p.transform(s)
+	if !keep {
+		return
+	}
+	p.SpanProcessor.OnEnd(transformedSpan{ReadOnlySpan: s, attributes: attrs})
+}
+
+// transformedSpan overrides attributes of a finished span.
+type transformedSpan struct {
+	tracesdk.ReadOnlySpan
+	attributes []attribute.KeyValue
+}
+
+// Attributes implements tracesdk.ReadOnlySpan.
+func (s transformedSpan) Attributes() []attribute.KeyValue {
+	return s.attributes
+}
+
+// attributeValueLengthLimit resolves the attribute value length limit.
+// Precedence from higher to lower:
+// 1. OTEL_SPAN_ATTRIBUTE_VALUE_LENGTH_LIMIT
+// 2. OTEL_ATTRIBUTE_VALUE_LENGTH_LIMIT
+// 3. TraceOptions.AttributeValueLengthLimit
+// Zero means unlimited.
+func attributeValueLengthLimit(options TraceOptions) int {
+	const me = "attributeValueLengthLimit"
+	for _, key := range []string{"OTEL_SPAN_ATTRIBUTE_VALUE_LENGTH_LIMIT", "OTEL_ATTRIBUTE_VALUE_LENGTH_LIMIT"} {
+		str := getEnv(me, key, options.Debug)
+		if str == "" {
+			continue
+		}
+		limit, err := strconv.Atoi(str)
+		if err != nil || limit < 0 {
+			log.Printf("%s: bad %s='%s': %v", me, key, str, err)
+			continue
+		}
+		return limit
+	}
+	return options.AttributeValueLengthLimit
+}
+
+// truncateProcessor truncates oversized attribute values of finished
+// spans, including event and link attributes, which the SDK span limits
+// do not cover.
+type truncateProcessor struct {
+	tracesdk.SpanProcessor
+	limit int
+}
+
+// OnEnd implements tracesdk.SpanProcessor.
+func (p truncateProcessor) OnEnd(s tracesdk.ReadOnlySpan) {
+	p.SpanProcessor.OnEnd(truncatedSpan{ReadOnlySpan: s, limit: p.limit})
+}
+
+// truncatedSpan truncates attribute values of a finished span.
+type truncatedSpan struct {
+	tracesdk.ReadOnlySpan
+	limit int
+}
+
+// Attributes implements tracesdk.ReadOnlySpan.
+func (s truncatedSpan) Attributes() []attribute.KeyValue {
+	return truncateAttributes(s.limit, s.ReadOnlySpan.Attributes())
+}
+
+// Events implements tracesdk.ReadOnlySpan.
+func (s truncatedSpan) Events() []tracesdk.Event {
+	events := s.ReadOnlySpan.Events()
+	if len(events) == 0 {
+		return events
+	}
+	result := make([]tracesdk.Event, 0, len(events))
+	for _, e := range events {
+		e.Attributes = truncateAttributes(s.limit, e.Attributes)
+		result = append(result, e)
+	}
+	return result
+}
+
+// Links implements tracesdk.ReadOnlySpan.
+func (s truncatedSpan) Links() []tracesdk.Link {
+	links := s.ReadOnlySpan.Links()
+	if len(links) == 0 {
+		return links
+	}
+	result := make([]tracesdk.Link, 0, len(links))
+	for _, l := range links {
+		l.Attributes = truncateAttributes(s.limit, l.Attributes)
+		result = append(result, l)
+	}
+	return result
+}
+
+// truncateAttributes truncates string and string slice values to limit
+// characters. The input slice is not modified.
+func truncateAttributes(limit int, attrs []attribute.KeyValue) []attribute.KeyValue {
+	var result []attribute.KeyValue
+	for i, a := range attrs {
+		t, changed := truncateAttribute(limit, a)
+		if changed && result == nil {
+			result = append(make([]attribute.KeyValue, 0, len(attrs)), attrs[:i]...)
+		}
+		if result != nil {
+			result = append(result, t)
+		}
+	}
+	if result == nil {
+		return attrs
+	}
+	return result
+}
+
+func truncateAttribute(limit int, a attribute.KeyValue) (attribute.KeyValue, bool) {
+	switch a.Value.Type() {
+	case attribute.STRING:
+		if v, changed := truncateString(limit, a.Value.AsString()); changed {
+			return a.Key.String(v), true
+		}
+	case attribute.STRINGSLICE:
+		values := a.Value.AsStringSlice()
+		var changed bool
+		for i, v := range values {
+			if t, c := truncateString(limit, v); c {
+				values[i] = t
+				changed = true
+			}
+		}
+		if changed {
+			return a.Key.StringSlice(values), true
+		}
+	}
+	return a, false
+}
+
+// truncateString truncates s to limit characters, keeping valid UTF-8.
+func truncateString(limit int, s string) (string, bool) {
+	if len(s) <= limit {
+		return s, false
+	}
+	var n int
+	for i := range s {
+		if n == limit {
+			return s[:i], true
+		}
+		n++
+	}
+	return s, false
+}
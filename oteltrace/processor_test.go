@@ -0,0 +1,76 @@
+package oteltrace
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestTruncateString(t *testing.T) {
+	table := []struct {
+		name    string
+		limit   int
+		input   string
+		want    string
+		changed bool
+	}{
+		{"empty", 3, "", "", false},
+		{"shorter", 3, "ab", "ab", false},
+		{"exact", 3, "abc", "abc", false},
+		{"longer", 3, "abcdef", "abc", true},
+		{"zero limit", 0, "abc", "", true},
+		{"multibyte fits by chars", 3, "ãéí", "ãéí", false},
+		{"multibyte boundary", 2, "ãéí", "ãé", true},
+		{"mixed", 4, "aã€bc", "aã€b", true},
+		{"4-byte runes", 1, "😀😀", "😀", true},
+	}
+
+	for _, data := range table {
+		t.Run(data.name, func(t *testing.T) {
+			got, changed := truncateString(data.limit, data.input)
+			if got != data.want || changed != data.changed {
+				t.Errorf("got ('%s',%t), want ('%s',%t)", got, changed, data.want, data.changed)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("invalid UTF-8: %q", got)
+			}
+		})
+	}
+}
+
+func TestTruncateAttributes(t *testing.T) {
+	attrs := []attribute.KeyValue{
+		attribute.String("short", "ab"),
+		attribute.Int("int", 123456),
+		attribute.String("long", "abcdef"),
+		attribute.StringSlice("slice", []string{"abcdef", "a"}),
+	}
+
+	got := truncateAttributes(3, attrs)
+
+	want := map[attribute.Key]attribute.Value{
+		"short": attribute.StringValue("ab"),
+		"int":   attribute.IntValue(123456),
+		"long":  attribute.StringValue("abc"),
+		"slice": attribute.StringSliceValue([]string{"abc", "a"}),
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d attributes, want %d", len(got), len(want))
+	}
+	for _, a := range got {
+		if a.Value.Emit() != want[a.Key].Emit() {
+			t.Errorf("%s: got '%s', want '%s'", a.Key, a.Value.Emit(), want[a.Key].Emit())
+		}
+	}
+
+	if attrs[2].Value.AsString() != "abcdef" {
+		t.Errorf("input attributes modified")
+	}
+
+	unchanged := attrs[:2]
+	if got := truncateAttributes(3, unchanged); &got[0] != &unchanged[0] {
+		t.Errorf("unchanged attributes should not be copied")
+	}
+}
@@ -0,0 +1,104 @@
+package oteltrace
+
+import (
+	"context"
+	"runtime/pprof"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// profileIDKey links a span to the profiling samples labeled with its ID.
+// Pyroscope span profiles use the same attribute.
+const profileIDKey = attribute.Key("pyroscope.profile.id")
+
+// profileRootKey keys the local root span labeled by ProfilingLabels in
+// a context.
+type profileRootKey struct{}
+
+// spanLabels returns the pprof labels for a span context.
+func spanLabels(sc trace.SpanContext, spanName string) pprof.LabelSet {
+	if spanName == "" {
+		return pprof.Labels(
+			"trace_id", sc.TraceID().String(),
+			"span_id", sc.SpanID().String(),
+		)
+	}
+	return pprof.Labels(
+		"trace_id", sc.TraceID().String(),
+		"span_id", sc.SpanID().String(),
+		"span_name", spanName,
+	)
+}
+
+// ProfileDo calls f with pprof labels trace_id and span_id taken from the
+// active span in ctx, by using pprof.Do. Goroutine and CPU profiles
+// collected while f runs can then be filtered by trace. Goroutines
+// started by f inherit the labels.
+//
+// If ctx carries labels set by ProfilingLabels for the local root span,
+// those labels are used, and the root span records attribute
+// pyroscope.profile.id, so the span links to the samples. If ctx carries
+// no valid span, f is called with ctx unchanged.
+//
+//	oteltrace.ProfileDo(ctx, func(ctx context.Context) {
+//		heavyWork(ctx)
+//	})
+func ProfileDo(ctx context.Context, f func(context.Context)) {
+	if spanID, found := pprof.Label(ctx, "span_id"); found {
+		if root, ok := ctx.Value(profileRootKey{}).(trace.Span); ok {
+			root.SetAttributes(profileIDKey.String(spanID))
+		}
+		pprof.Do(ctx, pprof.Labels(), f) // labels from ProfilingLabels
+		return
+	}
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		f(ctx)
+		return
+	}
+	pprof.Do(ctx, spanLabels(sc, ""), f)
+}
+
+// profilingProvider wraps a TracerProvider in order to correlate
+// profiling samples with spans.
+//
+// Local root spans (spans without parent, or with remote parent) attach
+// pprof labels trace_id, span_id and span_name to the returned context.
+// Labels are context-scoped: goroutine labels are never changed, so
+// spans may end on any goroutine. Run work under ProfileDo to apply the
+// labels to CPU and goroutine profiles, and to record the span ID as
+// attribute pyroscope.profile.id in the root span. Under plain pprof.Do,
+// samples are labeled but the attribute is not recorded.
+type profilingProvider struct {
+	trace.TracerProvider
+}
+
+// Tracer implements trace.TracerProvider.
+func (p profilingProvider) Tracer(name string, options ...trace.TracerOption) trace.Tracer {
+	return profilingTracer{Tracer: p.TracerProvider.Tracer(name, options...)}
+}
+
+type profilingTracer struct {
+	trace.Tracer
+}
+
+// Start implements trace.Tracer.
+func (t profilingTracer) Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	parent := trace.SpanContextFromContext(ctx)
+
+	newCtx, span := t.Tracer.Start(ctx, spanName, opts...)
+
+	if parent.IsValid() && !parent.IsRemote() {
+		return newCtx, span // only local roots are labeled
+	}
+
+	sc := span.SpanContext()
+	if !sc.IsSampled() {
+		return newCtx, span
+	}
+
+	newCtx = context.WithValue(newCtx, profileRootKey{}, span)
+
+	return pprof.WithLabels(newCtx, spanLabels(sc, spanName)), span
+}
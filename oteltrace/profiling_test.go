@@ -0,0 +1,52 @@
+package oteltrace
+
+import (
+	"context"
+	"testing"
+
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestProfilingAttribute(t *testing.T) {
+	table := []struct {
+		name      string
+		profileDo bool
+		want      bool
+	}{
+		{"without ProfileDo", false, false},
+		{"under ProfileDo", true, true},
+	}
+
+	for _, data := range table {
+		t.Run(data.name, func(t *testing.T) {
+			rec := tracetest.NewSpanRecorder()
+			tp := profilingProvider{TracerProvider: tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(rec))}
+			tracer := tp.Tracer("test")
+
+			ctx, root := tracer.Start(context.Background(), "root")
+			ctx, child := tracer.Start(ctx, "child")
+			if data.profileDo {
+				ProfileDo(ctx, func(context.Context) {})
+			}
+			child.End()
+			root.End()
+
+			for _, s := range rec.Ended() {
+				var found bool
+				for _, a := range s.Attributes() {
+					if a.Key != profileIDKey {
+						continue
+					}
+					found = true
+					if a.Value.AsString() != root.SpanContext().SpanID().String() {
+						t.Errorf("%s: profile id: got '%s', want root span ID", s.Name(), a.Value.AsString())
+					}
+				}
+				if want := data.want && s.Name() == "root"; found != want {
+					t.Errorf("%s: attribute %s: got %t, want %t", s.Name(), profileIDKey, found, want)
+				}
+			}
+		})
+	}
+}
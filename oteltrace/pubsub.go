@@ -0,0 +1,94 @@
+package oteltrace
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PubSubCarrier adapts Google Cloud Pub/Sub message attributes
+// (pubsub.Message.Attributes) to propagation.TextMapCarrier.
+type PubSubCarrier map[string]string
+
+// Get implements propagation.TextMapCarrier.
+func (c PubSubCarrier) Get(key string) string {
+	return c[key]
+}
+
+// Set implements propagation.TextMapCarrier.
+func (c PubSubCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+// Keys implements propagation.TextMapCarrier.
+func (c PubSubCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+var _ propagation.TextMapCarrier = PubSubCarrier(nil)
+
+// PubSubInject injects the trace context from ctx into Pub/Sub message
+// attributes, using the global propagator. It allocates attributes if nil.
+//
+//	msg := &pubsub.Message{Data: data}
+//	msg.Attributes = oteltrace.PubSubInject(ctx, msg.Attributes)
+//	topic.Publish(ctx, msg)
+func PubSubInject(ctx context.Context, attributes map[string]string) map[string]string {
+	if attributes == nil {
+		attributes = map[string]string{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, PubSubCarrier(attributes))
+	return attributes
+}
+
+// PubSubExtract extracts the trace context from Pub/Sub message
+// attributes into ctx, using the global propagator.
+func PubSubExtract(ctx context.Context, attributes map[string]string) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, PubSubCarrier(attributes))
+}
+
+// StartPubSubConsumerSpan starts a consumer span for processing a Pub/Sub
+// message received from subscription, as a child of the publisher span
+// carried in the message attributes. Attributes follow semantic
+// conventions v1.27.0. The subscription is recorded as
+// messaging.destination.subscription.name, since the topic is unknown to
+// the consumer:
+//
+//	sub.Receive(ctx, func(ctx context.Context, msg *pubsub.Message) {
+//		ctx, span := oteltrace.StartPubSubConsumerSpan(ctx, tracer, sub.ID(), msg.ID, msg.Attributes)
+//		defer span.End()
+//		// ...
+//		msg.Ack()
+//	})
+//
+// The rest of the package, including the resource schema URL, stays on
+// semantic conventions v1.4.0: v1.4.0 has no gcp_pubsub messaging system
+// nor subscription and operation type attributes, while moving resource
+// attributes to v1.27.0 would rename deployment.environment, set by
+// presets from DD_ENV and ELASTIC_APM_ENVIRONMENT, to
+// deployment.environment.name, breaking existing queries. Span attributes
+// carry no schema URL of their own, so backends match the v1.27.0 names
+// directly.
+func StartPubSubConsumerSpan(ctx context.Context, tracer trace.Tracer, subscription, messageID string,
+	attributes map[string]string) (context.Context, trace.Span) {
+
+	ctx = PubSubExtract(ctx, attributes)
+
+	return tracer.Start(ctx, subscription+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemGCPPubsub,
+			semconv.MessagingDestinationSubscriptionName(subscription),
+			semconv.MessagingOperationName("process"),
+			semconv.MessagingOperationTypeProcess,
+			semconv.MessagingMessageID(messageID),
+		),
+	)
+}
@@ -0,0 +1,45 @@
+package oteltrace
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestStartPubSubConsumerSpan(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tracer := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(rec)).Tracer("test")
+
+	_, span := StartPubSubConsumerSpan(context.Background(), tracer, "orders-sub", "msg-1", nil)
+	span.End()
+
+	spans := rec.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+
+	got := map[attribute.Key]string{}
+	for _, a := range spans[0].Attributes() {
+		got[a.Key] = a.Value.Emit()
+	}
+
+	want := map[attribute.Key]string{
+		"messaging.system":                        "gcp_pubsub",
+		"messaging.destination.subscription.name": "orders-sub",
+		"messaging.operation.name":                "process",
+		"messaging.operation.type":                "process",
+		"messaging.message.id":                    "msg-1",
+	}
+
+	if len(got) != len(want) {
+		t.Errorf("attributes: got %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("attribute %s: got '%s', want '%s'", k, got[k], v)
+		}
+	}
+}
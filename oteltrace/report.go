@@ -0,0 +1,102 @@
+package oteltrace
+
+import (
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Report describes what TraceStart configured, so that applications can
+// log it once in their own format and assert on it in integration tests.
+//
+//	ctx, tracer, cancel, r, err := oteltrace.TraceStartReport(ctx, options)
+//	...
+//	slog.Info("tracing", "exporter", r.Exporter, "endpoint", r.Endpoint)
+type Report struct {
+	// Noop tells the No-Op TracerProvider was installed: nothing is
+	// exported.
+	Noop bool
+
+	// Reused tells an installed global TracerProvider was reused under
+	// GlobalReuse. Other fields describe it only if it was installed by
+	// TraceStart, otherwise they are empty.
+	Reused bool
+
+	// Exporter is the exporter type: grpc, http, jaeger or stdout.
+	Exporter string
+
+	// Endpoint is the resolved endpoint: OTEL_EXPORTER_OTLP_TRACES_ENDPOINT
+	// if set, as a full URL, otherwise a base URL. Empty means the
+	// exporter default.
+	Endpoint string
+
+	// EndpointSource tells where Endpoint came from, like
+	// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT, OTEL_EXPORTER_OTLP_ENDPOINT or
+	// TraceOptions.Endpoint.
+	EndpointSource string
+
+	// TLS tells the main exporter uses TLS.
+	TLS bool
+
+	// Destinations lists endpoints of TraceOptions.Destinations.
+	Destinations []string
+
+	// Sampler is the sampler description, like
+	// ParentBased{root:AlwaysOnSampler,...}.
+	Sampler string
+
+	// EnvSampler describes the sampler from OTEL_TRACES_SAMPLER and
+	// OTEL_TRACES_SAMPLER_ARG. It differs from Sampler when
+	// TraceOptions.SamplerFunc replaces it.
+	EnvSampler string
+
+	// PropagatorFields lists header names used by the installed
+	// propagator, like traceparent and baggage.
+	PropagatorFields []string
+
+	// Resource holds the resource attributes attached to spans.
+	Resource []attribute.KeyValue
+}
+
+var report Report // protected by providerLock
+
+// setReport records the report for the provider installed by TraceStart.
+func setReport(r Report) {
+	providerLock.Lock()
+	report = r
+	providerLock.Unlock()
+}
+
+// LastReport returns the report of the last successful TraceStart, as a
+// convenience for code without access to the Report returned by
+// TraceStartReport. Before TraceStart, it returns the zero Report.
+func LastReport() Report {
+	providerLock.Lock()
+	r := report
+	providerLock.Unlock()
+	return r.clone()
+}
+
+// reusedReport records the report for a provider reused under
+// GlobalReuse. The report of the provider installed by TraceStart is
+// kept when sdk is that provider.
+func reusedReport(sdk *tracesdk.TracerProvider) Report {
+	providerLock.Lock()
+	r := report
+	if provider != sdk {
+		r = Report{}
+	}
+	r.Reused = true
+	report = r
+	providerLock.Unlock()
+	return r.clone()
+}
+
+// clone returns a copy of r not sharing slices.
+func (r Report) clone() Report {
+	r.Destinations = slices.Clone(r.Destinations)
+	r.PropagatorFields = slices.Clone(r.PropagatorFields)
+	r.Resource = slices.Clone(r.Resource)
+	return r
+}
@@ -0,0 +1,109 @@
+package oteltrace
+
+import (
+	"context"
+	"testing"
+
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestReportEndpoint(t *testing.T) {
+	table := []struct {
+		name           string
+		exporter       string
+		endpoint       string
+		tracesEndpoint string
+		expectEndpoint string
+		expectSource   string
+	}{
+		{"default", "http", "", "", "", ""},
+		{"base", "http", "http://base:4318", "", "http://base:4318", "OTEL_EXPORTER_OTLP_ENDPOINT"},
+		{"traces", "http", "http://base:4318", "http://traces:4318/v1/traces", "http://traces:4318/v1/traces", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"},
+		{"traces only", "grpc", "", "http://traces:4317", "http://traces:4317", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"},
+		{"jaeger ignores traces", "jaeger", "http://jaeger:14268", "http://traces:4318", "http://jaeger:14268", "OTEL_EXPORTER_OTLP_ENDPOINT"},
+	}
+
+	for _, data := range table {
+		t.Run(data.name, func(t *testing.T) {
+			keepGlobals(t)
+
+			t.Setenv("OTELCONFIG_EXPORTER", data.exporter)
+			t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", data.endpoint)
+			t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", data.tracesEndpoint)
+
+			_, cancel, err := TraceStart(TraceOptions{DefaultService: "test"})
+			if err != nil {
+				t.Fatalf("trace start: %v", err)
+			}
+			defer cancel()
+
+			r := LastReport()
+			if r.Endpoint != data.expectEndpoint {
+				t.Errorf("endpoint: got '%s', want '%s'", r.Endpoint, data.expectEndpoint)
+			}
+			if r.EndpointSource != data.expectSource {
+				t.Errorf("endpoint source: got '%s', want '%s'", r.EndpointSource, data.expectSource)
+			}
+		})
+	}
+}
+
+func TestReportEnvSampler(t *testing.T) {
+	keepGlobals(t)
+
+	t.Setenv("OTELCONFIG_EXPORTER", "stdout")
+	t.Setenv("OTEL_TRACES_SAMPLER", "always_off")
+	t.Setenv("OTEL_TRACES_SAMPLER_ARG", "")
+
+	options := TraceOptions{
+		DefaultService: "test",
+		SamplerFunc: func(tracesdk.SamplingParameters) tracesdk.SamplingDecision {
+			return tracesdk.RecordAndSample
+		},
+	}
+
+	_, cancel, err := TraceStart(options)
+	if err != nil {
+		t.Fatalf("trace start: %v", err)
+	}
+	defer cancel()
+
+	r := LastReport()
+	if want := tracesdk.NeverSample().Description(); r.EnvSampler != want {
+		t.Errorf("env sampler: got '%s', want '%s'", r.EnvSampler, want)
+	}
+	if r.Sampler == r.EnvSampler {
+		t.Errorf("sampler: got '%s', want SamplerFunc", r.Sampler)
+	}
+}
+
+func TestTraceStartReportReuse(t *testing.T) {
+	keepGlobals(t)
+
+	t.Setenv("OTELCONFIG_EXPORTER", "stdout")
+
+	_, _, cancel, first, err := TraceStartReport(context.Background(), TraceOptions{DefaultService: "test"})
+	if err != nil {
+		t.Fatalf("trace start: %v", err)
+	}
+	defer cancel()
+
+	if first.Reused || first.Exporter != "stdout" {
+		t.Errorf("first report: got %+v", first)
+	}
+
+	_, _, _, second, err := TraceStartReport(context.Background(), TraceOptions{
+		DefaultService: "test",
+		GlobalPolicy:   GlobalReuse,
+	})
+	if err != nil {
+		t.Fatalf("trace start reuse: %v", err)
+	}
+
+	if !second.Reused || second.Exporter != "stdout" {
+		t.Errorf("reused report: got %+v", second)
+	}
+	if r := LastReport(); !r.Reused {
+		t.Errorf("last report: got %+v, want reused", r)
+	}
+}
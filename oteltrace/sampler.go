@@ -0,0 +1,94 @@
+package oteltrace
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SamplerFunc decides per span whether it is sampled.
+// It can consult feature flags, tenant tier, etc., at sampling time.
+// The parent span context is available as params.ParentContext.
+type SamplerFunc func(params tracesdk.SamplingParameters) tracesdk.SamplingDecision
+
+// funcSampler adapts SamplerFunc to tracesdk.Sampler.
+type funcSampler struct {
+	decide SamplerFunc
+}
+
+// ShouldSample implements tracesdk.Sampler.
+func (s funcSampler) ShouldSample(params tracesdk.SamplingParameters) tracesdk.SamplingResult {
+	return tracesdk.SamplingResult{
+		Decision:   s.decide(params),
+		Tracestate: trace.SpanContextFromContext(params.ParentContext).TraceState(),
+	}
+}
+
+// Description implements tracesdk.Sampler.
+func (s funcSampler) Description() string {
+	return "SamplerFunc"
+}
+
+// samplerFromEnv builds the sampler from OTEL_TRACES_SAMPLER and
+// OTEL_TRACES_SAMPLER_ARG, like the SDK does internally.
+// Default is parentbased_always_on.
+func samplerFromEnv(debug bool) tracesdk.Sampler {
+	const me = "samplerFromEnv"
+
+	name := strings.ToLower(strings.TrimSpace(getEnv(me, "OTEL_TRACES_SAMPLER", debug)))
+	arg := strings.TrimSpace(getEnv(me, "OTEL_TRACES_SAMPLER_ARG", debug))
+
+	ratio := func() tracesdk.Sampler {
+		if arg == "" {
+			return tracesdk.TraceIDRatioBased(1.0)
+		}
+		v, err := strconv.ParseFloat(arg, 64)
+		if err != nil || v < 0 || v > 1 {
+			log.Printf("%s: bad OTEL_TRACES_SAMPLER_ARG='%s', using 1.0", me, arg)
+			return tracesdk.TraceIDRatioBased(1.0)
+		}
+		return tracesdk.TraceIDRatioBased(v)
+	}
+
+	switch name {
+	case "", "parentbased_always_on":
+		return tracesdk.ParentBased(tracesdk.AlwaysSample())
+	case "always_on":
+		return tracesdk.AlwaysSample()
+	case "always_off":
+		return tracesdk.NeverSample()
+	case "traceidratio":
+		return ratio()
+	case "parentbased_always_off":
+		return tracesdk.ParentBased(tracesdk.NeverSample())
+	case "parentbased_traceidratio":
+		return tracesdk.ParentBased(ratio())
+	}
+
+	log.Printf("%s: unsupported OTEL_TRACES_SAMPLER='%s', using parentbased_always_on", me, name)
+
+	return tracesdk.ParentBased(tracesdk.AlwaysSample())
+}
+
+// recordOnlySampler turns Drop decisions into RecordOnly, so that span
+// processors see every span, while only sampled spans are exported.
+type recordOnlySampler struct {
+	tracesdk.Sampler
+}
+
+// ShouldSample implements tracesdk.Sampler.
+func (s recordOnlySampler) ShouldSample(params tracesdk.SamplingParameters) tracesdk.SamplingResult {
+	result := s.Sampler.ShouldSample(params)
+	if result.Decision == tracesdk.Drop {
+		result.Decision = tracesdk.RecordOnly
+	}
+	return result
+}
+
+// Description implements tracesdk.Sampler.
+func (s recordOnlySampler) Description() string {
+	return "RecordOnly{" + s.Sampler.Description() + "}"
+}
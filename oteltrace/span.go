@@ -0,0 +1,116 @@
+package oteltrace
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Recover recovers a panic, records it in span as an exception event with
+// stack trace, sets error status, and ends the span. It ends the span
+// also when there is no panic, so it replaces span.End:
+//
+//	ctx, span := tracer.Start(ctx, "handler")
+//	defer oteltrace.Recover(ctx, span)
+//
+// If span is nil, the span from ctx is used.
+// The panic is swallowed, see RecoverRepanic to propagate it.
+func Recover(ctx context.Context, span trace.Span) {
+	if r := recover(); r != nil {
+		endPanic(ctx, span, r)
+		return
+	}
+	spanOrContext(ctx, span).End()
+}
+
+// RecoverRepanic is like Recover, but panics again with the
+// recovered value after the span is ended.
+func RecoverRepanic(ctx context.Context, span trace.Span) {
+	if r := recover(); r != nil {
+		endPanic(ctx, span, r)
+		panic(r)
+	}
+	spanOrContext(ctx, span).End()
+}
+
+// WithSpan runs fn within a new span named name. An error returned by fn
+// is recorded in the span with error status. A panic in fn is recorded
+// as in RecoverRepanic, then propagated.
+//
+//	err := oteltrace.WithSpan(ctx, tracer, "fetch", func(ctx context.Context) error {
+//		return fetch(ctx)
+//	})
+func WithSpan(ctx context.Context, tracer trace.Tracer, name string,
+	fn func(context.Context) error, opts ...trace.SpanStartOption) error {
+
+	ctx, span := tracer.Start(ctx, name, opts...)
+	defer RecoverRepanic(ctx, span)
+
+	err := fn(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return err
+}
+
+// AnnotateDeadline records in span the deadline of ctx and the time
+// remaining to it, as attributes context.deadline and
+// context.deadline.remaining_ms (negative when exceeded). If ctx is done,
+// it adds event context.done with the error and the cancellation cause.
+func AnnotateDeadline(ctx context.Context, span trace.Span) {
+	if deadline, ok := ctx.Deadline(); ok {
+		span.SetAttributes(
+			attribute.String("context.deadline", deadline.Format(time.RFC3339Nano)),
+			attribute.Int64("context.deadline.remaining_ms", time.Until(deadline).Milliseconds()),
+		)
+	}
+
+	err := ctx.Err()
+	if err == nil {
+		return
+	}
+
+	attrs := []attribute.KeyValue{attribute.String("context.error", err.Error())}
+	if cause := context.Cause(ctx); cause != nil && cause != err {
+		attrs = append(attrs, attribute.String("context.cause", cause.Error()))
+	}
+	span.AddEvent("context.done", trace.WithAttributes(attrs...))
+}
+
+// EndWithDeadline annotates span with AnnotateDeadline, then ends it.
+// It makes timeout-induced failures obvious in traces:
+//
+//	ctx, span := tracer.Start(ctx, "query")
+//	defer oteltrace.EndWithDeadline(ctx, span)
+func EndWithDeadline(ctx context.Context, span trace.Span, options ...trace.SpanEndOption) {
+	AnnotateDeadline(ctx, span)
+	span.End(options...)
+}
+
+// endPanic records the recovered panic value r in span and ends it.
+func endPanic(ctx context.Context, span trace.Span, r any) {
+	span = spanOrContext(ctx, span)
+
+	err, isErr := r.(error)
+	if !isErr {
+		err = fmt.Errorf("panic: %v", r)
+	}
+
+	// recorded from the deferred call, the stack trace shows the panic site
+	span.RecordError(err, trace.WithStackTrace(true))
+	span.SetStatus(codes.Error, err.Error())
+	span.End()
+}
+
+func spanOrContext(ctx context.Context, span trace.Span) trace.Span {
+	if span == nil {
+		return trace.SpanFromContext(ctx)
+	}
+	return span
+}
@@ -0,0 +1,123 @@
+package oteltrace
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// spanMetrics is a span processor deriving RED metrics from finished
+// spans, like the collector spanmetrics connector:
+//
+//	traces.span.metrics.calls    - counter: request rate, error rate (status.code=STATUS_CODE_ERROR)
+//	traces.span.metrics.duration - histogram: span duration in milliseconds
+//
+// Both have the connector dimensions service.name, span.name, span.kind
+// (like SPAN_KIND_SERVER) and status.code (like STATUS_CODE_UNSET), so
+// dashboards built for the connector apply.
+//
+// The cheaper span counter (TraceOptions.SpanCounter) is the metric
+// otelconfig.spans, with attributes span.name and status.code (like
+// Unset).
+//
+// Metrics are reported through the global MeterProvider.
+type spanMetrics struct {
+	spans    metric.Int64Counter     // nil unless span counter is enabled
+	calls    metric.Int64Counter     // nil unless RED metrics are enabled
+	duration metric.Float64Histogram // nil unless RED metrics are enabled
+}
+
+// newSpanMetrics creates the span counter if counter is true, and the
+// RED metrics if red is true.
+func newSpanMetrics(counter, red bool) (*spanMetrics, error) {
+	meter := otel.GetMeterProvider().Meter(lib)
+
+	var m spanMetrics
+
+	if counter {
+		spans, err := meter.Int64Counter("otelconfig.spans",
+			metric.WithDescription("Finished spans by name and status."),
+			metric.WithUnit("{span}"),
+		)
+		if err != nil {
+			return nil, err
+		}
+		m.spans = spans
+	}
+
+	if !red {
+		return &m, nil
+	}
+
+	calls, err := meter.Int64Counter("traces.span.metrics.calls",
+		metric.WithDescription("Finished spans by name, kind and status."),
+		metric.WithUnit("{call}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	duration, err := meter.Float64Histogram("traces.span.metrics.duration",
+		metric.WithDescription("Span duration by name, kind and status."),
+		metric.WithUnit("ms"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	m.calls = calls
+	m.duration = duration
+
+	return &m, nil
+}
+
+// OnStart implements tracesdk.SpanProcessor.
+func (m *spanMetrics) OnStart(_ context.Context, _ tracesdk.ReadWriteSpan) {}
+
+// OnEnd implements tracesdk.SpanProcessor.
+func (m *spanMetrics) OnEnd(s tracesdk.ReadOnlySpan) {
+	status := s.Status().Code
+
+	name := attribute.String("span.name", s.Name())
+
+	ctx := context.Background()
+
+	if m.spans != nil {
+		m.spans.Add(ctx, 1, metric.WithAttributes(name,
+			attribute.String("status.code", status.String())))
+	}
+
+	if m.calls == nil {
+		return
+	}
+
+	var service string
+	if r := s.Resource(); r != nil {
+		value, _ := r.Set().Value(semconv.ServiceNameKey)
+		service = value.AsString()
+	}
+
+	// values like the spanmetrics connector
+	attrs := metric.WithAttributes(
+		semconv.ServiceNameKey.String(service),
+		name,
+		attribute.String("span.kind", "SPAN_KIND_"+strings.ToUpper(s.SpanKind().String())),
+		attribute.String("status.code", "STATUS_CODE_"+strings.ToUpper(status.String())),
+	)
+
+	elapsed := s.EndTime().Sub(s.StartTime())
+
+	m.calls.Add(ctx, 1, attrs)
+	m.duration.Record(ctx, float64(elapsed.Microseconds())/1000, attrs)
+}
+
+// Shutdown implements tracesdk.SpanProcessor.
+func (m *spanMetrics) Shutdown(context.Context) error { return nil }
+
+// ForceFlush implements tracesdk.SpanProcessor.
+func (m *spanMetrics) ForceFlush(context.Context) error { return nil }
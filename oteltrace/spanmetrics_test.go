@@ -0,0 +1,71 @@
+package oteltrace
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/sdk/resource"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// attrCounter records the attributes of the last Add.
+type attrCounter struct {
+	noop.Int64Counter
+	attrs attribute.Set
+}
+
+func (c *attrCounter) Add(_ context.Context, _ int64, options ...metric.AddOption) {
+	c.attrs = metric.NewAddConfig(options).Attributes()
+}
+
+func (c *attrCounter) get(key attribute.Key) string {
+	value, _ := c.attrs.Value(key)
+	return value.AsString()
+}
+
+func TestSpanMetricsStatus(t *testing.T) {
+	table := []struct {
+		status      codes.Code
+		wantCounter string
+		wantCalls   string
+	}{
+		{codes.Unset, "Unset", "STATUS_CODE_UNSET"},
+		{codes.Ok, "Ok", "STATUS_CODE_OK"},
+		{codes.Error, "Error", "STATUS_CODE_ERROR"},
+	}
+
+	for _, data := range table {
+		t.Run(data.status.String(), func(t *testing.T) {
+			spans := &attrCounter{}
+			calls := &attrCounter{}
+			m := spanMetrics{spans: spans, calls: calls, duration: noop.Float64Histogram{}}
+
+			span := tracetest.SpanStub{
+				Name:     "work",
+				SpanKind: trace.SpanKindServer,
+				Status:   tracesdk.Status{Code: data.status},
+				Resource: resource.NewSchemaless(attribute.String("service.name", "svc")),
+			}.Snapshot()
+			m.OnEnd(span)
+
+			if got := spans.get("status.code"); got != data.wantCounter {
+				t.Errorf("otelconfig.spans status: got '%s', want '%s'", got, data.wantCounter)
+			}
+			if got := calls.get("status.code"); got != data.wantCalls {
+				t.Errorf("calls status: got '%s', want '%s'", got, data.wantCalls)
+			}
+			if got := calls.get("span.kind"); got != "SPAN_KIND_SERVER" {
+				t.Errorf("calls span kind: got '%s', want 'SPAN_KIND_SERVER'", got)
+			}
+			if got := calls.get("service.name"); got != "svc" {
+				t.Errorf("calls service name: got '%s', want 'svc'", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,659 @@
+package oteltrace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	spoolDefaultMaxBytes = 100 << 20 // 100 MiB
+	spoolDefaultMaxAge   = 24 * time.Hour
+	spoolReplayBatches   = 10 // max batches replayed per successful export
+	spoolReplayFailures  = 3  // failed replays before a batch is discarded
+)
+
+// spoolConfig holds the disk buffer settings.
+type spoolConfig struct {
+	dir      string
+	maxBytes int64
+	maxAge   time.Duration
+}
+
+// spoolConfigFromOptions resolves the disk buffer settings.
+// OTELCONFIG_SPOOL_DIR, OTELCONFIG_SPOOL_MAX_BYTES and
+// OTELCONFIG_SPOOL_MAX_AGE override options.
+func spoolConfigFromOptions(options TraceOptions) spoolConfig {
+	const me = "spoolConfigFromOptions"
+
+	debug := options.Debug
+
+	cfg := spoolConfig{
+		dir:      options.SpoolDir,
+		maxBytes: options.SpoolMaxBytes,
+		maxAge:   options.SpoolMaxAge,
+	}
+
+	if str := getEnv(me, "OTELCONFIG_SPOOL_DIR", debug); str != "" {
+		cfg.dir = str
+	}
+
+	if str := getEnv(me, "OTELCONFIG_SPOOL_MAX_BYTES", debug); str != "" {
+		v, err := strconv.ParseInt(str, 10, 64)
+		if err != nil || v < 0 {
+			log.Printf("%s: bad OTELCONFIG_SPOOL_MAX_BYTES='%s'", me, str)
+		} else {
+			cfg.maxBytes = v
+		}
+	}
+
+	if str := getEnv(me, "OTELCONFIG_SPOOL_MAX_AGE", debug); str != "" {
+		v, err := time.ParseDuration(str)
+		if err != nil || v < 0 {
+			log.Printf("%s: bad OTELCONFIG_SPOOL_MAX_AGE='%s'", me, str)
+		} else {
+			cfg.maxAge = v
+		}
+	}
+
+	if cfg.maxBytes == 0 {
+		cfg.maxBytes = spoolDefaultMaxBytes
+	}
+	if cfg.maxAge == 0 {
+		cfg.maxAge = spoolDefaultMaxAge
+	}
+
+	return cfg
+}
+
+// spoolExporter writes batches that failed to export to disk, and
+// replays them after a later export succeeds, so that collector
+// maintenance windows do not lose traces. The spool is capped by size
+// and age: oldest batches are discarded first.
+//
+// Batches rejected permanently by the collector are not spooled. A
+// spooled batch that keeps failing for non-transient reasons is
+// discarded after a few replays, so it does not block later batches.
+type spoolExporter struct {
+	tracesdk.SpanExporter
+	cfg   spoolConfig
+	debug bool
+
+	mutex    sync.Mutex
+	seq      int
+	failures map[string]int // failed replays by batch file
+}
+
+// newSpoolExporter wraps exp with a disk buffer in cfg.dir.
+func newSpoolExporter(exp tracesdk.SpanExporter, cfg spoolConfig, debug bool) (*spoolExporter, error) {
+	const me = "newSpoolExporter"
+	if err := os.MkdirAll(cfg.dir, 0o700); err != nil {
+		return nil, fmt.Errorf("%s: %w", me, err)
+	}
+	if debug {
+		log.Printf("%s: dir='%s' max_bytes=%d max_age=%v",
+			me, cfg.dir, cfg.maxBytes, cfg.maxAge)
+	}
+	return &spoolExporter{
+		SpanExporter: exp,
+		cfg:          cfg,
+		debug:        debug,
+		failures:     map[string]int{},
+	}, nil
+}
+
+// ExportSpans implements tracesdk.SpanExporter.
+// A failed batch is spooled, and the export error is still returned.
+func (e *spoolExporter) ExportSpans(ctx context.Context, spans []tracesdk.ReadOnlySpan) error {
+	const me = "spoolExporter.ExportSpans"
+
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if err != nil {
+		if permanentError(err) {
+			return err // replay would be rejected as well
+		}
+		if errSpool := e.spool(spans); errSpool != nil {
+			log.Printf("%s: spans lost: %v", me, errSpool)
+			return err
+		}
+		return fmt.Errorf("%s: %d spans spooled to disk: %w", me, len(spans), err)
+	}
+
+	e.replay(ctx)
+
+	return nil
+}
+
+// permanentError tells the collector rejected the export for good, like
+// gRPC InvalidArgument, so retrying the same batch cannot succeed.
+func permanentError(err error) bool {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() == codes.Unknown {
+		return false // not a gRPC status, maybe transient
+	}
+	return !transientCode(st.Code())
+}
+
+// transientError tells the export failure is likely temporary, like an
+// unreachable collector, so the batch should be kept for later replay.
+func transientError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	st, ok := status.FromError(err)
+	return ok && transientCode(st.Code())
+}
+
+// transientCode tells whether gRPC code is retryable per the OTLP
+// specification.
+func transientCode(code codes.Code) bool {
+	switch code {
+	case codes.Canceled, codes.DeadlineExceeded, codes.ResourceExhausted,
+		codes.Aborted, codes.OutOfRange, codes.Unavailable, codes.DataLoss:
+		return true
+	}
+	return false
+}
+
+// spoolFile is a spooled batch file.
+type spoolFile struct {
+	path string
+	size int64
+}
+
+// files lists spooled batches, oldest first, discarding expired ones.
+func (e *spoolExporter) files() []spoolFile {
+	const me = "spoolExporter.files"
+
+	entries, err := os.ReadDir(e.cfg.dir)
+	if err != nil {
+		log.Printf("%s: %v", me, err)
+		return nil
+	}
+
+	var list []spoolFile
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		info, errInfo := entry.Info()
+		if errInfo != nil {
+			continue
+		}
+		path := filepath.Join(e.cfg.dir, entry.Name())
+		if time.Since(info.ModTime()) > e.cfg.maxAge {
+			log.Printf("%s: discarding expired batch: %s", me, path)
+			e.remove(path)
+			continue
+		}
+		list = append(list, spoolFile{path: path, size: info.Size()})
+	}
+
+	// file names start with the creation time
+	sort.Slice(list, func(i, j int) bool { return list[i].path < list[j].path })
+
+	return list
+}
+
+// spool writes spans to a new batch file, discarding oldest batches
+// to stay within the size cap.
+func (e *spoolExporter) spool(spans []tracesdk.ReadOnlySpan) error {
+	const me = "spoolExporter.spool"
+
+	batch := make([]spoolSpan, 0, len(spans))
+	for _, s := range spans {
+		batch = append(batch, newSpoolSpan(s))
+	}
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("%s: encode: %w", me, err)
+	}
+
+	size := int64(len(data))
+	if size > e.cfg.maxBytes {
+		return fmt.Errorf("%s: batch size %d exceeds spool max bytes %d", me, size, e.cfg.maxBytes)
+	}
+
+	list := e.files()
+	var total int64
+	for _, f := range list {
+		total += f.size
+	}
+	for len(list) > 0 && total+size > e.cfg.maxBytes {
+		log.Printf("%s: spool full, discarding oldest batch: %s", me, list[0].path)
+		e.remove(list[0].path)
+		total -= list[0].size
+		list = list[1:]
+	}
+
+	e.seq++
+	name := fmt.Sprintf("%020d-%06d.json", time.Now().UnixNano(), e.seq%1000000)
+	path := filepath.Join(e.cfg.dir, name)
+
+	// write and rename, so that replay never sees partial files
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("%s: write: %w", me, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("%s: rename: %w", me, err)
+	}
+
+	if e.debug {
+		log.Printf("%s: spooled %d spans: %s", me, len(spans), path)
+	}
+
+	return nil
+}
+
+// remove deletes a spooled batch file.
+func (e *spoolExporter) remove(path string) {
+	os.Remove(path)
+	delete(e.failures, path)
+}
+
+// replay exports spooled batches, oldest first, stopping on the first
+// transient failure. A batch failing for other reasons is skipped, and
+// discarded after spoolReplayFailures attempts. It replays a limited
+// number of batches per call, in order to not hold back live spans.
+func (e *spoolExporter) replay(ctx context.Context) {
+	const me = "spoolExporter.replay"
+
+	list := e.files()
+
+	for i, f := range list {
+		if i >= spoolReplayBatches || ctx.Err() != nil {
+			return
+		}
+
+		data, err := os.ReadFile(f.path)
+		if err != nil {
+			log.Printf("%s: %v", me, err)
+			continue
+		}
+
+		var batch []spoolSpan
+		if err := json.Unmarshal(data, &batch); err != nil {
+			log.Printf("%s: discarding corrupt batch: %s: %v", me, f.path, err)
+			e.remove(f.path)
+			continue
+		}
+
+		spans := make([]tracesdk.ReadOnlySpan, 0, len(batch))
+		for _, s := range batch {
+			spans = append(spans, s.snapshot())
+		}
+
+		if err := e.SpanExporter.ExportSpans(ctx, spans); err != nil {
+			log.Printf("%s: %s: %v", me, f.path, err)
+			if transientError(err) {
+				return
+			}
+			e.failures[f.path]++
+			if permanentError(err) || e.failures[f.path] >= spoolReplayFailures {
+				log.Printf("%s: discarding rejected batch after %d attempts: %s",
+					me, e.failures[f.path], f.path)
+				e.remove(f.path)
+			}
+			continue
+		}
+
+		e.remove(f.path)
+
+		if e.debug {
+			log.Printf("%s: replayed %d spans: %s", me, len(spans), f.path)
+		}
+	}
+}
+
+// spoolSpan is the disk format of a span.
+type spoolSpan struct {
+	Name              string           `json:"name"`
+	SpanContext       spoolSpanContext `json:"span_context"`
+	Parent            spoolSpanContext `json:"parent"`
+	SpanKind          trace.SpanKind   `json:"kind"`
+	StartTime         time.Time        `json:"start"`
+	EndTime           time.Time        `json:"end"`
+	Attributes        []spoolAttribute `json:"attributes,omitempty"`
+	Events            []spoolEvent     `json:"events,omitempty"`
+	Links             []spoolLink      `json:"links,omitempty"`
+	StatusCode        otelcodes.Code   `json:"status_code"`
+	StatusDescription string           `json:"status_description,omitempty"`
+	DroppedAttributes int              `json:"dropped_attributes,omitempty"`
+	DroppedEvents     int              `json:"dropped_events,omitempty"`
+	DroppedLinks      int              `json:"dropped_links,omitempty"`
+	ChildSpanCount    int              `json:"child_span_count,omitempty"`
+	Resource          []spoolAttribute `json:"resource,omitempty"`
+	ResourceSchemaURL string           `json:"resource_schema_url,omitempty"`
+	Scope             spoolScope       `json:"scope"`
+}
+
+type spoolSpanContext struct {
+	TraceID    string `json:"trace_id"`
+	SpanID     string `json:"span_id"`
+	TraceFlags byte   `json:"flags"`
+	TraceState string `json:"trace_state,omitempty"`
+	Remote     bool   `json:"remote,omitempty"`
+}
+
+type spoolEvent struct {
+	Name              string           `json:"name"`
+	Time              time.Time        `json:"time"`
+	Attributes        []spoolAttribute `json:"attributes,omitempty"`
+	DroppedAttributes int              `json:"dropped_attributes,omitempty"`
+}
+
+type spoolLink struct {
+	SpanContext       spoolSpanContext `json:"span_context"`
+	Attributes        []spoolAttribute `json:"attributes,omitempty"`
+	DroppedAttributes int              `json:"dropped_attributes,omitempty"`
+}
+
+type spoolScope struct {
+	Name       string           `json:"name"`
+	Version    string           `json:"version,omitempty"`
+	SchemaURL  string           `json:"schema_url,omitempty"`
+	Attributes []spoolAttribute `json:"attributes,omitempty"`
+}
+
+// spoolAttribute keeps the value type, since plain JSON would turn
+// integers into floats.
+type spoolAttribute struct {
+	Key     string    `json:"k"`
+	Type    string    `json:"t"`
+	Bool    bool      `json:"b,omitempty"`
+	Int     int64     `json:"i,omitempty"`
+	Float   float64   `json:"f,omitempty"`
+	String  string    `json:"s,omitempty"`
+	Bools   []bool    `json:"bs,omitempty"`
+	Ints    []int64   `json:"is,omitempty"`
+	Floats  []float64 `json:"fs,omitempty"`
+	Strings []string  `json:"ss,omitempty"`
+}
+
+func newSpoolSpan(s tracesdk.ReadOnlySpan) spoolSpan {
+	scope := s.InstrumentationScope()
+
+	span := spoolSpan{
+		Name:              s.Name(),
+		SpanContext:       newSpoolSpanContext(s.SpanContext()),
+		Parent:            newSpoolSpanContext(s.Parent()),
+		SpanKind:          s.SpanKind(),
+		StartTime:         s.StartTime(),
+		EndTime:           s.EndTime(),
+		Attributes:        newSpoolAttributes(s.Attributes()),
+		StatusCode:        s.Status().Code,
+		StatusDescription: s.Status().Description,
+		DroppedAttributes: s.DroppedAttributes(),
+		DroppedEvents:     s.DroppedEvents(),
+		DroppedLinks:      s.DroppedLinks(),
+		ChildSpanCount:    s.ChildSpanCount(),
+		Scope: spoolScope{
+			Name:       scope.Name,
+			Version:    scope.Version,
+			SchemaURL:  scope.SchemaURL,
+			Attributes: newSpoolAttributes(scope.Attributes.ToSlice()),
+		},
+	}
+
+	for _, ev := range s.Events() {
+		span.Events = append(span.Events, spoolEvent{
+			Name:              ev.Name,
+			Time:              ev.Time,
+			Attributes:        newSpoolAttributes(ev.Attributes),
+			DroppedAttributes: ev.DroppedAttributeCount,
+		})
+	}
+
+	for _, l := range s.Links() {
+		span.Links = append(span.Links, spoolLink{
+			SpanContext:       newSpoolSpanContext(l.SpanContext),
+			Attributes:        newSpoolAttributes(l.Attributes),
+			DroppedAttributes: l.DroppedAttributeCount,
+		})
+	}
+
+	if r := s.Resource(); r != nil {
+		span.Resource = newSpoolAttributes(r.Attributes())
+		span.ResourceSchemaURL = r.SchemaURL()
+	}
+
+	return span
+}
+
+// snapshot rebuilds the span for export.
+func (s spoolSpan) snapshot() tracesdk.ReadOnlySpan {
+	span := replayedSpan{
+		name:        s.Name,
+		spanContext: s.SpanContext.spanContext(),
+		parent:      s.Parent.spanContext(),
+		spanKind:    s.SpanKind,
+		startTime:   s.StartTime,
+		endTime:     s.EndTime,
+		attributes:  spoolAttributes(s.Attributes),
+		status: tracesdk.Status{
+			Code:        s.StatusCode,
+			Description: s.StatusDescription,
+		},
+		droppedAttributes: s.DroppedAttributes,
+		droppedEvents:     s.DroppedEvents,
+		droppedLinks:      s.DroppedLinks,
+		childSpanCount:    s.ChildSpanCount,
+		resource:          resource.NewWithAttributes(s.ResourceSchemaURL, spoolAttributes(s.Resource)...),
+		scope: instrumentation.Scope{
+			Name:       s.Scope.Name,
+			Version:    s.Scope.Version,
+			SchemaURL:  s.Scope.SchemaURL,
+			Attributes: attribute.NewSet(spoolAttributes(s.Scope.Attributes)...),
+		},
+	}
+
+	for _, ev := range s.Events {
+		span.events = append(span.events, tracesdk.Event{
+			Name:                  ev.Name,
+			Time:                  ev.Time,
+			Attributes:            spoolAttributes(ev.Attributes),
+			DroppedAttributeCount: ev.DroppedAttributes,
+		})
+	}
+
+	for _, l := range s.Links {
+		span.links = append(span.links, tracesdk.Link{
+			SpanContext:           l.SpanContext.spanContext(),
+			Attributes:            spoolAttributes(l.Attributes),
+			DroppedAttributeCount: l.DroppedAttributes,
+		})
+	}
+
+	return span
+}
+
+// replayedSpan is a finished span read back from the spool.
+type replayedSpan struct {
+	// embedded for the unexported interface method only: every
+	// other method is implemented by replayedSpan.
+	tracesdk.ReadOnlySpan
+
+	name              string
+	spanContext       trace.SpanContext
+	parent            trace.SpanContext
+	spanKind          trace.SpanKind
+	startTime         time.Time
+	endTime           time.Time
+	attributes        []attribute.KeyValue
+	events            []tracesdk.Event
+	links             []tracesdk.Link
+	status            tracesdk.Status
+	droppedAttributes int
+	droppedEvents     int
+	droppedLinks      int
+	childSpanCount    int
+	resource          *resource.Resource
+	scope             instrumentation.Scope
+}
+
+// Name implements tracesdk.ReadOnlySpan.
+func (s replayedSpan) Name() string { return s.name }
+
+// SpanContext implements tracesdk.ReadOnlySpan.
+func (s replayedSpan) SpanContext() trace.SpanContext { return s.spanContext }
+
+// Parent implements tracesdk.ReadOnlySpan.
+func (s replayedSpan) Parent() trace.SpanContext { return s.parent }
+
+// SpanKind implements tracesdk.ReadOnlySpan.
+func (s replayedSpan) SpanKind() trace.SpanKind { return s.spanKind }
+
+// StartTime implements tracesdk.ReadOnlySpan.
+func (s replayedSpan) StartTime() time.Time { return s.startTime }
+
+// EndTime implements tracesdk.ReadOnlySpan.
+func (s replayedSpan) EndTime() time.Time { return s.endTime }
+
+// Attributes implements tracesdk.ReadOnlySpan.
+func (s replayedSpan) Attributes() []attribute.KeyValue { return s.attributes }
+
+// Links implements tracesdk.ReadOnlySpan.
+func (s replayedSpan) Links() []tracesdk.Link { return s.links }
+
+// Events implements tracesdk.ReadOnlySpan.
+func (s replayedSpan) Events() []tracesdk.Event { return s.events }
+
+// Status implements tracesdk.ReadOnlySpan.
+func (s replayedSpan) Status() tracesdk.Status { return s.status }
+
+// InstrumentationScope implements tracesdk.ReadOnlySpan.
+func (s replayedSpan) InstrumentationScope() instrumentation.Scope { return s.scope }
+
+// InstrumentationLibrary implements tracesdk.ReadOnlySpan.
+func (s replayedSpan) InstrumentationLibrary() instrumentation.Library {
+	return s.scope
+}
+
+// Resource implements tracesdk.ReadOnlySpan.
+func (s replayedSpan) Resource() *resource.Resource { return s.resource }
+
+// DroppedAttributes implements tracesdk.ReadOnlySpan.
+func (s replayedSpan) DroppedAttributes() int { return s.droppedAttributes }
+
+// DroppedLinks implements tracesdk.ReadOnlySpan.
+func (s replayedSpan) DroppedLinks() int { return s.droppedLinks }
+
+// DroppedEvents implements tracesdk.ReadOnlySpan.
+func (s replayedSpan) DroppedEvents() int { return s.droppedEvents }
+
+// ChildSpanCount implements tracesdk.ReadOnlySpan.
+func (s replayedSpan) ChildSpanCount() int { return s.childSpanCount }
+
+func newSpoolSpanContext(sc trace.SpanContext) spoolSpanContext {
+	return spoolSpanContext{
+		TraceID:    sc.TraceID().String(),
+		SpanID:     sc.SpanID().String(),
+		TraceFlags: byte(sc.TraceFlags()),
+		TraceState: sc.TraceState().String(),
+		Remote:     sc.IsRemote(),
+	}
+}
+
+func (c spoolSpanContext) spanContext() trace.SpanContext {
+	// invalid (zero) ids fail to parse and are kept zero, like for root
+	// spans without parent.
+	traceID, _ := trace.TraceIDFromHex(c.TraceID)
+	spanID, _ := trace.SpanIDFromHex(c.SpanID)
+	state, _ := trace.ParseTraceState(c.TraceState)
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.TraceFlags(c.TraceFlags),
+		TraceState: state,
+		Remote:     c.Remote,
+	})
+}
+
+func newSpoolAttributes(attrs []attribute.KeyValue) []spoolAttribute {
+	if len(attrs) == 0 {
+		return nil
+	}
+	list := make([]spoolAttribute, 0, len(attrs))
+	for _, kv := range attrs {
+		a := spoolAttribute{Key: string(kv.Key), Type: kv.Value.Type().String()}
+		switch kv.Value.Type() {
+		case attribute.BOOL:
+			a.Bool = kv.Value.AsBool()
+		case attribute.INT64:
+			a.Int = kv.Value.AsInt64()
+		case attribute.FLOAT64:
+			a.Float = kv.Value.AsFloat64()
+		case attribute.STRING:
+			a.String = kv.Value.AsString()
+		case attribute.BOOLSLICE:
+			a.Bools = kv.Value.AsBoolSlice()
+		case attribute.INT64SLICE:
+			a.Ints = kv.Value.AsInt64Slice()
+		case attribute.FLOAT64SLICE:
+			a.Floats = kv.Value.AsFloat64Slice()
+		case attribute.STRINGSLICE:
+			a.Strings = kv.Value.AsStringSlice()
+		default:
+			continue
+		}
+		list = append(list, a)
+	}
+	return list
+}
+
+func spoolAttributes(list []spoolAttribute) []attribute.KeyValue {
+	if len(list) == 0 {
+		return nil
+	}
+	attrs := make([]attribute.KeyValue, 0, len(list))
+	for _, a := range list {
+		switch a.Type {
+		case attribute.BOOL.String():
+			attrs = append(attrs, attribute.Bool(a.Key, a.Bool))
+		case attribute.INT64.String():
+			attrs = append(attrs, attribute.Int64(a.Key, a.Int))
+		case attribute.FLOAT64.String():
+			attrs = append(attrs, attribute.Float64(a.Key, a.Float))
+		case attribute.STRING.String():
+			attrs = append(attrs, attribute.String(a.Key, a.String))
+		case attribute.BOOLSLICE.String():
+			attrs = append(attrs, attribute.BoolSlice(a.Key, a.Bools))
+		case attribute.INT64SLICE.String():
+			attrs = append(attrs, attribute.Int64Slice(a.Key, a.Ints))
+		case attribute.FLOAT64SLICE.String():
+			attrs = append(attrs, attribute.Float64Slice(a.Key, a.Floats))
+		case attribute.STRINGSLICE.String():
+			attrs = append(attrs, attribute.StringSlice(a.Key, a.Strings))
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,255 @@
+package oteltrace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSpoolRoundTrip(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("0102030405060708090a0b0c0d0e0f10")
+	state, _ := trace.ParseTraceState("vendor=value")
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+		TraceFlags: trace.FlagsSampled,
+		TraceState: state,
+	})
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  trace.SpanID{8, 7, 6, 5, 4, 3, 2, 1},
+		Remote:  true,
+	})
+	start := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+
+	attrs := []attribute.KeyValue{
+		attribute.Bool("b", true),
+		attribute.Int64("i", 42),
+		attribute.Float64("f", 1.5),
+		attribute.String("s", "text"),
+		attribute.BoolSlice("bs", []bool{true, false}),
+		attribute.Int64Slice("is", []int64{1, 2}),
+		attribute.Float64Slice("fs", []float64{0.5, 2.5}),
+		attribute.StringSlice("ss", []string{"a", "b"}),
+	}
+
+	want := tracetest.SpanStub{
+		Name:        "work",
+		SpanContext: sc,
+		Parent:      parent,
+		SpanKind:    trace.SpanKindServer,
+		StartTime:   start,
+		EndTime:     start.Add(time.Second),
+		Attributes:  attrs,
+		Events: []tracesdk.Event{{
+			Name:                  "event",
+			Time:                  start.Add(time.Millisecond),
+			Attributes:            []attribute.KeyValue{attribute.Int("n", 1)},
+			DroppedAttributeCount: 2,
+		}},
+		Links: []tracesdk.Link{{
+			SpanContext:           parent,
+			Attributes:            []attribute.KeyValue{attribute.String("l", "x")},
+			DroppedAttributeCount: 3,
+		}},
+		Status:            tracesdk.Status{Code: otelcodes.Error, Description: "failed"},
+		DroppedAttributes: 4,
+		DroppedEvents:     5,
+		DroppedLinks:      6,
+		ChildSpanCount:    7,
+		Resource:          resource.NewWithAttributes("https://schema", attribute.String("service.name", "svc")),
+		InstrumentationScope: instrumentation.Scope{
+			Name:       "scope",
+			Version:    "v1",
+			SchemaURL:  "https://scope-schema",
+			Attributes: attribute.NewSet(attribute.String("a", "b")),
+		},
+	}
+
+	data, err := json.Marshal(newSpoolSpan(want.Snapshot()))
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	var decoded spoolSpan
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	got := tracetest.SpanStubFromReadOnlySpan(decoded.snapshot())
+
+	if !got.Resource.Equal(want.Resource) || got.Resource.SchemaURL() != want.Resource.SchemaURL() {
+		t.Errorf("resource: got %v, want %v", got.Resource, want.Resource)
+	}
+	got.Resource, want.Resource = nil, nil
+	want.InstrumentationLibrary = want.InstrumentationScope // filled by snapshots
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip mismatch:\ngot:  %+v\nwant: %+v", got, want)
+	}
+}
+
+// fakeExporter exports spans unless fail returns an error for the
+// first span name of the batch.
+type fakeExporter struct {
+	fail     func(name string) error
+	exported []string
+}
+
+func (e *fakeExporter) ExportSpans(_ context.Context, spans []tracesdk.ReadOnlySpan) error {
+	if err := e.fail(spans[0].Name()); err != nil {
+		return err
+	}
+	for _, s := range spans {
+		e.exported = append(e.exported, s.Name())
+	}
+	return nil
+}
+
+func (e *fakeExporter) Shutdown(context.Context) error { return nil }
+
+func spoolBatch(name string) []tracesdk.ReadOnlySpan {
+	return []tracesdk.ReadOnlySpan{tracetest.SpanStub{Name: name}.Snapshot()}
+}
+
+func newTestSpool(t *testing.T, fail func(string) error, maxBytes int64) (*spoolExporter, *fakeExporter) {
+	t.Helper()
+	fake := &fakeExporter{fail: fail}
+	cfg := spoolConfig{dir: t.TempDir(), maxBytes: maxBytes, maxAge: time.Hour}
+	e, err := newSpoolExporter(fake, cfg, false)
+	if err != nil {
+		t.Fatalf("spool exporter: %v", err)
+	}
+	return e, fake
+}
+
+func TestSpoolReplay(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "collector down")
+	rejected := errors.New("failed to send: 400 Bad Request")
+
+	table := []struct {
+		name         string
+		fail         map[string][]error // errors returned by successive exports of a batch
+		batches      []string
+		wantExported []string
+		wantSpooled  int
+	}{
+		{
+			name:         "replay in order",
+			fail:         map[string][]error{"a": {unavailable}, "b": {unavailable}},
+			batches:      []string{"a", "b", "live"},
+			wantExported: []string{"live", "a", "b"},
+		},
+		{
+			name:        "permanent gRPC error not spooled",
+			fail:        map[string][]error{"a": {status.Error(codes.InvalidArgument, "bad")}},
+			batches:     []string{"a"},
+			wantSpooled: 0,
+		},
+		{
+			name:         "transient replay failure keeps order",
+			fail:         map[string][]error{"a": {unavailable, unavailable}, "b": {unavailable}},
+			batches:      []string{"a", "b", "live"},
+			wantExported: []string{"live"},
+			wantSpooled:  2,
+		},
+		{
+			name:         "rejected batch does not block later batches",
+			fail:         map[string][]error{"a": {unavailable, rejected, rejected, rejected}, "b": {unavailable}},
+			batches:      []string{"a", "b", "live1", "live2", "live3"},
+			wantExported: []string{"live1", "b", "live2", "live3"},
+			wantSpooled:  0,
+		},
+	}
+
+	for _, data := range table {
+		t.Run(data.name, func(t *testing.T) {
+			fail := func(name string) error {
+				errs := data.fail[name]
+				if len(errs) == 0 {
+					return nil
+				}
+				data.fail[name] = errs[1:]
+				return errs[0]
+			}
+
+			e, fake := newTestSpool(t, fail, spoolDefaultMaxBytes)
+
+			for _, b := range data.batches {
+				e.ExportSpans(context.Background(), spoolBatch(b))
+			}
+
+			if !slices.Equal(fake.exported, data.wantExported) {
+				t.Errorf("exported: got %v, want %v", fake.exported, data.wantExported)
+			}
+			if got := len(e.files()); got != data.wantSpooled {
+				t.Errorf("spooled batches: got %d, want %d", got, data.wantSpooled)
+			}
+		})
+	}
+}
+
+func TestSpoolLimits(t *testing.T) {
+	down := func(string) error { return status.Error(codes.Unavailable, "collector down") }
+
+	t.Run("size cap discards oldest", func(t *testing.T) {
+		data, _ := json.Marshal([]spoolSpan{newSpoolSpan(spoolBatch("a")[0])})
+		e, _ := newTestSpool(t, down, int64(2*len(data)))
+
+		for _, b := range []string{"a", "b", "c"} {
+			e.ExportSpans(context.Background(), spoolBatch(b))
+		}
+
+		var names []string
+		for _, f := range e.files() {
+			raw, err := os.ReadFile(f.path)
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			var batch []spoolSpan
+			if err := json.Unmarshal(raw, &batch); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			names = append(names, batch[0].Name)
+		}
+		if want := []string{"b", "c"}; !slices.Equal(names, want) {
+			t.Errorf("spooled: got %v, want %v", names, want)
+		}
+	})
+
+	t.Run("age cap discards expired", func(t *testing.T) {
+		e, _ := newTestSpool(t, down, spoolDefaultMaxBytes)
+
+		e.ExportSpans(context.Background(), spoolBatch("a"))
+		list := e.files()
+		if len(list) != 1 {
+			t.Fatalf("spooled batches: got %d, want 1", len(list))
+		}
+
+		old := time.Now().Add(-2 * time.Hour)
+		if err := os.Chtimes(list[0].path, old, old); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+
+		if got := len(e.files()); got != 0 {
+			t.Errorf("spooled batches: got %d, want 0", got)
+		}
+		if _, err := os.Stat(list[0].path); !os.IsNotExist(err) {
+			t.Errorf("expired batch not removed: %v", err)
+		}
+	})
+}
@@ -0,0 +1,307 @@
+package oteltrace
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tlsConfigFromEnv builds the OTLP client TLS config from env vars:
+//
+//	OTEL_EXPORTER_OTLP_CERTIFICATE        - CA bundle to verify the server
+//	OTEL_EXPORTER_OTLP_CLIENT_CERTIFICATE - client certificate for mTLS
+//	OTEL_EXPORTER_OTLP_CLIENT_KEY         - client private key for mTLS
+//
+// The per-signal OTEL_EXPORTER_OTLP_TRACES_* variants take precedence.
+// It returns nil config when none of the env vars is set.
+//
+// If reloadInterval is positive, the files are checked for changes at
+// most once per interval, during TLS handshakes, and reloaded on
+// rotation without restarting the process. host is the endpoint host,
+// verified against the server certificate when the handshake carries no
+// server name, as it happens for IP addresses.
+func tlsConfigFromEnv(reloadInterval time.Duration, host string, debug bool) (*tls.Config, error) {
+	const me = "tlsConfigFromEnv"
+
+	caFile := getSignalEnv(me, "CERTIFICATE", debug)
+	certFile := getSignalEnv(me, "CLIENT_CERTIFICATE", debug)
+	keyFile := getSignalEnv(me, "CLIENT_KEY", debug)
+
+	if caFile == "" && certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caFile != "" {
+		pool, err := loadCertPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, fmt.Errorf("%s: client certificate and client key must be set together: certificate='%s' key='%s'",
+				me, certFile, keyFile)
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s: load client certificate: %w", me, err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if reloadInterval > 0 {
+		r := &certReloader{
+			caFile:   caFile,
+			certFile: certFile,
+			keyFile:  keyFile,
+			host:     host,
+			interval: reloadInterval,
+			pool:     cfg.RootCAs,
+			checked:  time.Now(),
+			debug:    debug,
+		}
+		if len(cfg.Certificates) > 0 {
+			r.cert = &cfg.Certificates[0]
+		}
+		r.modified = r.modTime()
+		r.configure(cfg)
+	}
+
+	return cfg, nil
+}
+
+// certReloader reloads CA and client certificate files on rotation.
+type certReloader struct {
+	caFile   string
+	certFile string
+	keyFile  string
+	host     string // verified when the handshake has no server name
+	interval time.Duration
+	debug    bool
+
+	mutex    sync.Mutex
+	checked  time.Time // last check for changes
+	modified time.Time // latest modification time of files
+	pool     *x509.CertPool
+	cert     *tls.Certificate
+}
+
+// configure makes cfg take certificates from the reloader.
+func (r *certReloader) configure(cfg *tls.Config) {
+	if r.cert != nil {
+		cfg.Certificates = nil
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			r.reload()
+			r.mutex.Lock()
+			defer r.mutex.Unlock()
+			return r.cert, nil
+		}
+	}
+
+	if r.pool != nil {
+		// RootCAs is fixed in the config, so the server certificate is
+		// verified by VerifyConnection against the current pool.
+		cfg.RootCAs = nil
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyConnection = r.verifyConnection
+	}
+}
+
+// verifyConnection verifies the server certificate chain like the
+// standard verification, using the current CA pool.
+func (r *certReloader) verifyConnection(cs tls.ConnectionState) error {
+	r.reload()
+
+	const me = "verifyConnection"
+
+	if len(cs.PeerCertificates) == 0 {
+		return fmt.Errorf("%s: server sent no certificate", me)
+	}
+
+	// IP addresses are not sent as server name (SNI)
+	name := cs.ServerName
+	if name == "" {
+		name = r.host
+	}
+
+	// an empty name would skip host name verification
+	if name == "" {
+		return fmt.Errorf("%s: missing server name for host name verification", me)
+	}
+
+	r.mutex.Lock()
+	pool := r.pool
+	r.mutex.Unlock()
+
+	opts := x509.VerifyOptions{
+		Roots:         pool,
+		DNSName:       name,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, c := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+
+	_, err := cs.PeerCertificates[0].Verify(opts)
+	return err
+}
+
+// reload reloads the files if the interval elapsed and any file changed.
+// On failure, the current certificates are kept.
+func (r *certReloader) reload() {
+	const me = "certReloader.reload"
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if time.Since(r.checked) < r.interval {
+		return
+	}
+	r.checked = time.Now()
+
+	modified := r.modTime()
+	if !modified.After(r.modified) {
+		return
+	}
+
+	if r.caFile != "" {
+		pool, err := loadCertPool(r.caFile)
+		if err != nil {
+			log.Printf("%s: keeping current CA: %v", me, err)
+			return
+		}
+		r.pool = pool
+	}
+
+	if r.certFile != "" {
+		cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+		if err != nil {
+			log.Printf("%s: keeping current client certificate: %v", me, err)
+			return
+		}
+		r.cert = &cert
+	}
+
+	r.modified = modified
+
+	if r.debug {
+		log.Printf("%s: reloaded TLS certificates", me)
+	}
+}
+
+// modTime returns the latest modification time of the files.
+func (r *certReloader) modTime() time.Time {
+	var latest time.Time
+	for _, f := range []string{r.caFile, r.certFile, r.keyFile} {
+		if f == "" {
+			continue
+		}
+		info, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+// loadCertPool loads a PEM CA bundle.
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	const me = "loadCertPool"
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("%s: read CA certificate: %w", me, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%s: no PEM certificate found in CA file: %s", me, caFile)
+	}
+	return pool, nil
+}
+
+// endpointHost returns the host of the endpoint used by the OTLP
+// exporter: OTEL_EXPORTER_OTLP_TRACES_ENDPOINT if set, otherwise
+// endpoint, otherwise the exporter default localhost.
+func endpointHost(endpoint string, debug bool) string {
+	const me = "endpointHost"
+	if traces := getEnv(me, "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", debug); traces != "" {
+		endpoint = traces
+	}
+	if endpoint == "" {
+		return "localhost"
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Hostname() == "" {
+		return "localhost" // reported by endpoint validation
+	}
+	return u.Hostname()
+}
+
+// getSignalEnv retrieves OTEL_EXPORTER_OTLP_TRACES_<suffix>, falling back
+// to OTEL_EXPORTER_OTLP_<suffix>.
+func getSignalEnv(caller, suffix string, debug bool) string {
+	if value := getEnv(caller, "OTEL_EXPORTER_OTLP_TRACES_"+suffix, debug); value != "" {
+		return value
+	}
+	return getEnv(caller, "OTEL_EXPORTER_OTLP_"+suffix, debug)
+}
+
+// secureTransport decides whether OTLP clients should use TLS.
+//
+// An https:// endpoint means TLS, an http:// endpoint means plaintext.
+// An unset endpoint uses TLS if certificates are configured or
+// OTEL_EXPORTER_OTLP_INSECURE=false, plaintext otherwise.
+func secureTransport(endpoint string, hasCerts, debug bool) bool {
+	const me = "secureTransport"
+
+	if traces := getEnv(me, "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", debug); traces != "" {
+		endpoint = traces
+	}
+
+	scheme, _, found := strings.Cut(endpoint, "://")
+	if !found {
+		scheme = ""
+	}
+
+	var secure bool
+
+	switch strings.ToLower(scheme) {
+	case "https":
+		secure = true
+	case "http":
+		if hasCerts {
+			log.Printf("%s: ignoring TLS certificates for plaintext endpoint '%s', use https:// for TLS",
+				me, endpoint)
+		}
+	default:
+		secure = hasCerts
+		if str := getSignalEnv(me, "INSECURE", debug); str != "" {
+			insecure, err := strconv.ParseBool(str)
+			if err != nil {
+				log.Printf("%s: bad OTEL_EXPORTER_OTLP_INSECURE='%s': %v", me, str, err)
+			} else if !insecure {
+				secure = true
+			}
+		}
+	}
+
+	if debug {
+		log.Printf("%s: endpoint='%s' certificates=%t secure=%t",
+			me, endpoint, hasCerts, secure)
+	}
+
+	return secure
+}
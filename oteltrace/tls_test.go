@@ -0,0 +1,69 @@
+package oteltrace
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTLSReloadIPEndpoint(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, ca, 0o600); err != nil {
+		t.Fatalf("write CA: %v", err)
+	}
+
+	t.Setenv("OTEL_EXPORTER_OTLP_CERTIFICATE", caFile)
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+
+	table := []struct {
+		name  string
+		host  string
+		valid bool
+	}{
+		{"ip endpoint", endpointHost(server.URL, false), true},
+		{"wrong host", "10.0.0.1", false},
+	}
+
+	for _, data := range table {
+		t.Run(data.name, func(t *testing.T) {
+			cfg, err := tlsConfigFromEnv(time.Minute, data.host, false)
+			if err != nil {
+				t.Fatalf("tls config: %v", err)
+			}
+
+			client := http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+			defer client.CloseIdleConnections()
+
+			resp, errGet := client.Get(server.URL)
+			if errGet == nil {
+				resp.Body.Close()
+			}
+			if data.valid && errGet != nil {
+				t.Errorf("unexpected error: %v", errGet)
+			}
+			if !data.valid && errGet == nil {
+				t.Errorf("expected verification error for host '%s'", data.host)
+			}
+		})
+	}
+}
+
+func TestTraceStartNoopSkipsTLS(t *testing.T) {
+	keepGlobals(t)
+
+	t.Setenv("OTEL_EXPORTER_OTLP_CERTIFICATE", filepath.Join(t.TempDir(), "missing.pem"))
+
+	_, cancel, err := TraceStart(TraceOptions{DefaultService: "test", NoopTracerProvider: true})
+	if err != nil {
+		t.Fatalf("trace start: %v", err)
+	}
+	cancel()
+}
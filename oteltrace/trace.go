@@ -3,15 +3,21 @@ package oteltrace
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log"
+	"maps"
 	"net/url"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"go.opentelemetry.io/contrib/propagators/autoprop"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/jaeger"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
@@ -23,6 +29,7 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	"go.opentelemetry.io/otel/trace"
 	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc/credentials"
 )
 
 const lib = "github.com/udhos/otelconfig"
@@ -33,6 +40,221 @@ type TraceOptions struct {
 	NoopTracerProvider bool // Disable tracer
 	NoopPropagator     bool // Disable propagator
 	Debug              bool
+
+	// Preset names a registered preset applied to these options.
+	// OTELCONFIG_PRESET takes precedence over it.
+	// See RegisterPreset.
+	Preset string
+
+	// Exporter is used when OTELCONFIG_EXPORTER is unset.
+	Exporter string
+
+	// Endpoint is used when OTEL_EXPORTER_OTLP_ENDPOINT and
+	// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT are unset. Like
+	// OTEL_EXPORTER_OTLP_ENDPOINT, it is a base URL: the http exporter
+	// appends /v1/traces to its path.
+	Endpoint string
+
+	// Headers are sent with every OTLP export request.
+	// OTEL_EXPORTER_OTLP_HEADERS and OTEL_EXPORTER_OTLP_TRACES_HEADERS
+	// take precedence over them.
+	Headers map[string]string
+
+	// ResourceAttributes are recorded in the trace resource.
+	// OTEL_RESOURCE_ATTRIBUTES takes precedence over them.
+	ResourceAttributes []attribute.KeyValue
+
+	// IDGenerator generates trace and span IDs.
+	// If nil, the SDK default random generator is used.
+	IDGenerator tracesdk.IDGenerator
+
+	// SamplerFunc decides per span whether it is sampled.
+	// It replaces the sampler from OTEL_TRACES_SAMPLER.
+	SamplerFunc SamplerFunc
+
+	// ProfilingLabels correlates profiling samples with spans.
+	// Local root spans attach pprof labels trace_id, span_id and
+	// span_name to their context. It does nothing for profiles by
+	// itself: run work under ProfileDo to apply the labels, which also
+	// records the span ID as attribute pyroscope.profile.id in the root
+	// span, so one can jump from a slow span to its CPU profile.
+	ProfilingLabels bool
+
+	// Lambda enables Lambda-friendly mode when running in AWS Lambda,
+	// detected by AWS_LAMBDA_FUNCTION_NAME. Spans are exported
+	// synchronously as they end, instead of batched, and the function is
+	// described in resource attributes. Call ForceFlush at the end of every
+	// invocation, so spans are not lost when the execution environment
+	// freezes:
+	//
+	//	func handler(ctx context.Context, event Event) error {
+	//		defer oteltrace.ForceFlush(ctx)
+	//		// ...
+	//	}
+	Lambda bool
+
+	// GlobalPolicy defines behavior when a SDK TracerProvider is already
+	// installed as global. Default is GlobalReplace.
+	GlobalPolicy GlobalPolicy
+
+	// DiscoverCollector looks for the collector Kubernetes service when
+	// no endpoint is configured. OTELCONFIG_DISCOVER_COLLECTOR=true also
+	// enables it. See CollectorService.
+	DiscoverCollector bool
+
+	// CollectorService is the Kubernetes service name used by
+	// DiscoverCollector, optionally qualified by namespace, like
+	// otel-collector.monitoring. Default is opentelemetry-collector
+	// in the pod namespace.
+	CollectorService string
+
+	// SpanCounter counts finished spans by name and status in the metric
+	// otelconfig.spans, reported through the global MeterProvider
+	// (see otel.SetMeterProvider), giving cheap RED-style visibility.
+	// Spans not sampled are recorded, in order to be counted, but not
+	// exported.
+	SpanCounter bool
+
+	// SpanMetrics derives request rate, error rate and duration histograms
+	// from finished spans, like the collector spanmetrics connector, for
+	// teams that cannot run a collector. Metrics traces.span.metrics.calls
+	// and traces.span.metrics.duration carry the connector dimensions and
+	// values, like status.code=STATUS_CODE_ERROR, and are reported through
+	// the global MeterProvider (see otel.SetMeterProvider). Spans not
+	// sampled are recorded, in order to be measured, but not exported.
+	SpanMetrics bool
+
+	// InjectPropagators adds header formats injected in addition to
+	// OTEL_PROPAGATORS, like []string{"tracecontext", "b3multi"}.
+	// Incoming requests are extracted from any of the formats, with
+	// precedence to OTEL_PROPAGATORS. OTELCONFIG_PROPAGATORS_INJECT
+	// (comma-separated) overrides it.
+	InjectPropagators []string
+
+	// DefaultPropagators replaces the default propagators used when
+	// OTEL_PROPAGATORS is unset, like []string{"tracecontext", "baggage"}
+	// or []string{"b3multi"} for Istio meshes. Names follow
+	// OTEL_PROPAGATORS. If empty, the default is tracecontext.
+	DefaultPropagators []string
+
+	// BaggageAllowKeys restricts baggage propagated by this service to
+	// these keys, preventing accidental PII propagation. Nil allows any
+	// key. OTELCONFIG_BAGGAGE_ALLOW (comma-separated) overrides it.
+	BaggageAllowKeys []string
+
+	// BaggageMaxMembers caps the number of baggage members propagated.
+	// Zero means unlimited. OTELCONFIG_BAGGAGE_MAX_MEMBERS overrides it.
+	BaggageMaxMembers int
+
+	// BaggageMaxBytes caps the size of the propagated baggage header.
+	// Zero means unlimited. OTELCONFIG_BAGGAGE_MAX_BYTES overrides it.
+	BaggageMaxBytes int
+
+	// OnEnd is invoked for each finished span before export. It can drop
+	// the span or change the exported attributes, implementing policies
+	// without writing a full SpanProcessor. See SpanTransform.
+	OnEnd SpanTransform
+
+	// Expvar publishes telemetry pipeline health (queue depth, queued,
+	// dropped and exported spans, export latency and failures) in expvar
+	// map "otelconfig".
+	// Only the main exporter is recorded, not Destinations.
+	// OTELCONFIG_EXPVAR=true also enables it.
+	Expvar bool
+
+	// AttributeValueLengthLimit truncates string attribute values longer
+	// than this number of characters before export, including event and
+	// link attributes, so giant values (SQL statements, payloads) do not
+	// exceed collector payload limits. Zero means unlimited.
+	// OTEL_SPAN_ATTRIBUTE_VALUE_LENGTH_LIMIT and
+	// OTEL_ATTRIBUTE_VALUE_LENGTH_LIMIT take precedence over it.
+	AttributeValueLengthLimit int
+
+	// SyncExport exports every span synchronously as it ends, instead of
+	// batching, for interactive troubleshooting of exports.
+	// It slows down the application: NOT FOR PRODUCTION.
+	// OTELCONFIG_SYNC_EXPORT=true also enables it.
+	SyncExport bool
+
+	// Clock provides timestamps for span start, span end and span events,
+	// unless explicit timestamps are given, so that simulation tests and
+	// replay tooling produce deterministic timings. Batch scheduling still
+	// follows the real time. If nil, time.Now is used.
+	Clock func() time.Time
+
+	// TLSReloadInterval enables reloading of the OTLP CA and client
+	// certificate files on rotation, checking for changes at most once
+	// per interval, without restarting the process. New certificates
+	// apply to new connections. Zero disables reloading.
+	// OTELCONFIG_TLS_RELOAD_INTERVAL (like 5m) overrides it.
+	TLSReloadInterval time.Duration
+
+	// Destinations adds export destinations, each with its own endpoint,
+	// protocol and headers, receiving every span exported by the main
+	// exporter. See Destination.
+	Destinations []Destination
+
+	// SpoolDir enables a disk buffer for the main exporter: batches that
+	// fail to export are written to this directory and replayed after
+	// exports succeed again, so collector maintenance windows do not
+	// lose traces. Spooled batches survive restarts. Batches rejected by
+	// the collector are not kept, so they do not block replay.
+	// OTELCONFIG_SPOOL_DIR overrides it.
+	SpoolDir string
+
+	// SpoolMaxBytes caps the disk buffer size, discarding oldest batches.
+	// Default is 100 MiB. OTELCONFIG_SPOOL_MAX_BYTES overrides it.
+	SpoolMaxBytes int64
+
+	// SpoolMaxAge discards spooled batches older than this.
+	// Default is 24h. OTELCONFIG_SPOOL_MAX_AGE overrides it.
+	SpoolMaxAge time.Duration
+}
+
+var (
+	providerLock sync.Mutex
+	provider     *tracesdk.TracerProvider // SDK provider installed by TraceStart
+	installed    trace.TracerProvider     // provider installed by TraceStart, maybe wrapped
+)
+
+// setProvider records the provider installed by TraceStart.
+func setProvider(tp trace.TracerProvider, sdk *tracesdk.TracerProvider) {
+	providerLock.Lock()
+	installed = tp
+	provider = sdk
+	providerLock.Unlock()
+}
+
+// Tracer returns a tracer from the provider installed by TraceStart, so
+// that deeply nested packages do not need the tracer threaded through
+// constructors. Before TraceStart, it returns a No-Op Tracer.
+// The optional name defaults to this library name.
+//
+//	_, span := oteltrace.Tracer("mypkg").Start(ctx, "work")
+func Tracer(name ...string) trace.Tracer {
+	providerLock.Lock()
+	tp := installed
+	providerLock.Unlock()
+	if tp == nil {
+		return NewNoopTracer()
+	}
+	if len(name) > 0 && name[0] != "" {
+		return tp.Tracer(name[0])
+	}
+	return tp.Tracer(lib)
+}
+
+// ForceFlush immediately exports all ended spans not yet exported by
+// the tracer provider installed by TraceStart. It does nothing if
+// TraceStart was not called or tracing is disabled.
+func ForceFlush(ctx context.Context) error {
+	providerLock.Lock()
+	p := provider
+	providerLock.Unlock()
+	if p == nil {
+		return nil
+	}
+	return p.ForceFlush(ctx)
 }
 
 // NewNoopTracer creates a No-Op Tracer.
@@ -44,6 +266,15 @@ func NewNoopTracer() trace.Tracer {
 //
 // These env vars become available for customization at runtime:
 //
+//	# OTLP protocol, when OTELCONFIG_EXPORTER is unset: grpc, http/protobuf
+//	export OTEL_EXPORTER_OTLP_TRACES_PROTOCOL=grpc
+//
+//	# Apply a registered preset (see RegisterPreset)
+//	export OTELCONFIG_PRESET=datadog
+//
+//	# SDK internal logging: error, warn, info, debug
+//	export OTEL_LOG_LEVEL=warn
+//
 //	# Example for Jaeger
 //	export OTELCONFIG_EXPORTER=jaeger
 //	export OTEL_TRACES_EXPORTER=jaeger
@@ -56,18 +287,155 @@ func NewNoopTracer() trace.Tracer {
 //	export OTEL_PROPAGATORS=b3multi
 //	export OTEL_EXPORTER_OTLP_ENDPOINT=http://jaeger-collector:4317
 //
+//	# TLS: CA bundle and optional mTLS client credentials (PEM files)
+//	export OTEL_EXPORTER_OTLP_CERTIFICATE=/etc/otel/ca.pem
+//	export OTEL_EXPORTER_OTLP_CLIENT_CERTIFICATE=/etc/otel/client.pem
+//	export OTEL_EXPORTER_OTLP_CLIENT_KEY=/etc/otel/client-key.pem
+//
 //	# Example for HTTP and OTLP
 //	export OTELCONFIG_EXPORTER=http
 //	export OTEL_TRACES_EXPORTER=otlp
 //	export OTEL_PROPAGATORS=b3multi
 //	export OTEL_EXPORTER_OTLP_ENDPOINT=http://jaeger-collector:4318
+//
+// Use TraceStartContext to obtain the context carrying OTELCONFIG_BAGGAGE.
 func TraceStart(options TraceOptions) (trace.Tracer, func(), error) {
+	_, tracer, clean, err := TraceStartContext(context.Background(), options)
+	return tracer, clean, err
+}
+
+// TraceStartContext is like TraceStart, but also returns a context
+// derived from ctx carrying the baggage members from OTELCONFIG_BAGGAGE,
+// in W3C baggage format. Spans started from the returned context propagate
+// that run-level metadata.
+//
+//	export OTELCONFIG_BAGGAGE=run.id=1234,job.name=nightly-report
+func TraceStartContext(ctx context.Context, options TraceOptions) (context.Context, trace.Tracer, func(), error) {
+	ctx, tracer, clean, _, err := TraceStartReport(ctx, options)
+	return ctx, tracer, clean, err
+}
+
+// TraceStartReport is like TraceStartContext, but also returns the Report
+// describing what was configured, so that applications can log it once
+// in their own format and assert on it in integration tests.
+// LastReport returns the same Report.
+func TraceStartReport(ctx context.Context, options TraceOptions) (context.Context, trace.Tracer, func(), Report, error) {
 
-	const me = "TraceStart"
+	const me = "TraceStartReport"
 
-	exporter := getEnv(me, "OTELCONFIG_EXPORTER", options.Debug)
+	sdkLogging(options.Debug)
 
-	otelEndpoint := getEnv(me, "OTEL_EXPORTER_OTLP_ENDPOINT", options.Debug)
+	ctx, errBaggage := contextWithEnvBaggage(ctx, options.Debug)
+	if errBaggage != nil {
+		return ctx, nil, func() {}, Report{}, fmt.Errorf("%s: %w", me, errBaggage)
+	}
+
+	if existing, sdk := installedProvider(); existing != nil {
+		switch options.GlobalPolicy {
+		case GlobalReuse:
+			if options.Debug {
+				log.Printf("%s: reusing installed global TracerProvider", me)
+			}
+			rep := reusedReport(sdk)
+			setProvider(existing, sdk)
+			return ctx, existing.Tracer(lib), func() {}, rep, nil
+		case GlobalError:
+			return ctx, nil, func() {}, Report{}, fmt.Errorf("%s: %w", me, ErrProviderInstalled)
+		}
+		if options.Debug {
+			log.Printf("%s: replacing installed global TracerProvider", me)
+		}
+	}
+
+	preset := getEnv(me, "OTELCONFIG_PRESET", options.Debug)
+	if preset == "" {
+		preset = options.Preset
+	}
+	if preset != "" {
+		// presets may change headers, do not touch caller's map.
+		options.Headers = maps.Clone(options.Headers)
+		if err := applyPreset(preset, &options); err != nil {
+			return ctx, nil, func() {}, Report{}, fmt.Errorf("%s: %w", me, err)
+		}
+	}
+
+	exporter, errExporter := selectExporter(options)
+	if errExporter != nil {
+		return ctx, nil, func() {}, Report{}, fmt.Errorf("%s: %w", me, errExporter)
+	}
+
+	cfg := exportConfig{
+		exporter:       exporter,
+		endpoint:       getEnv(me, "OTEL_EXPORTER_OTLP_ENDPOINT", options.Debug),
+		endpointSource: "OTEL_EXPORTER_OTLP_ENDPOINT",
+		headers:        headersWithEnv(options.Headers),
+		debug:          options.Debug,
+	}
+
+	var destinations []exportConfig
+
+	if !options.NoopTracerProvider {
+		cfg.resolveEndpoint(options)
+		if err := validateEndpoints(cfg); err != nil {
+			return ctx, nil, func() {}, Report{}, fmt.Errorf("%s: %w", me, err)
+		}
+		dests, errDest := destinationConfigs(options)
+		if errDest != nil {
+			return ctx, nil, func() {}, Report{}, fmt.Errorf("%s: %w", me, errDest)
+		}
+		destinations = dests
+
+		tlsConfig, errTLS := tlsConfigFromEnv(tlsReloadInterval(options),
+			endpointHost(cfg.endpoint, options.Debug), options.Debug)
+		if errTLS != nil {
+			return ctx, nil, func() {}, Report{}, fmt.Errorf("%s: %w", me, errTLS)
+		}
+		switch {
+		case !secureTransport(cfg.endpoint, tlsConfig != nil, options.Debug):
+			tlsConfig = nil
+		case tlsConfig == nil:
+			// TLS with system root CAs
+			tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		cfg.tlsConfig = tlsConfig
+	}
+
+	var prop propagation.TextMapPropagator
+	if !options.NoopPropagator {
+		p, errProp := tracePropagation(options)
+		if errProp != nil {
+			return ctx, nil, func() {}, Report{}, fmt.Errorf("%s: %w", me, errProp)
+		}
+		prop = p
+	}
+
+	rep := Report{
+		Noop:           options.NoopTracerProvider,
+		Exporter:       cfg.exporter,
+		Endpoint:       cfg.endpoint,
+		EndpointSource: cfg.endpointSource,
+		TLS:            cfg.tlsConfig != nil,
+	}
+	if prop != nil {
+		rep.PropagatorFields = prop.Fields()
+		slices.Sort(rep.PropagatorFields) // composite propagator order is random
+	}
+	if rep.Exporter == "" {
+		rep.Exporter = "grpc"
+	}
+	// the SDK uses OTEL_EXPORTER_OTLP_TRACES_ENDPOINT as is (jaeger does not)
+	if cfg.exporter != "jaeger" {
+		if traces := getEnv(me, "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", false); traces != "" {
+			rep.Endpoint = traces
+			rep.EndpointSource = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+		}
+	}
+	if rep.Endpoint == "" {
+		rep.EndpointSource = ""
+	}
+	for _, d := range destinations {
+		rep.Destinations = append(rep.Destinations, d.endpoint)
+	}
 
 	var tp trace.TracerProvider
 	clean := func() {}
@@ -75,12 +443,20 @@ func TraceStart(options TraceOptions) (trace.Tracer, func(), error) {
 	if options.NoopTracerProvider {
 		tp = noop.NewTracerProvider()
 	} else {
-		p, errTracer := tracerProvider(options.DefaultService, exporter, otelEndpoint, options.Debug)
+		p, errTracer := tracerProvider(options, cfg, destinations, &rep)
 		if errTracer != nil {
-			return nil, clean, errTracer
+			return ctx, nil, clean, Report{}, errTracer
 		}
 		tp = p
 
+		if options.Clock != nil {
+			tp = clockProvider{TracerProvider: tp, now: options.Clock}
+		}
+
+		if options.ProfilingLabels {
+			tp = profilingProvider{TracerProvider: tp}
+		}
+
 		// Invoke clean to shutdown cleanly and flush telemetry when the application exits.
 		clean = func() {
 			ctx, cancel1 := context.WithCancel(context.Background())
@@ -98,11 +474,14 @@ func TraceStart(options TraceOptions) (trace.Tracer, func(), error) {
 	// instrumentation in the future will default to using it.
 	otel.SetTracerProvider(tp)
 
-	if !options.NoopPropagator {
-		tracePropagation(options.Debug)
+	setProvider(tp, sdkProvider(tp))
+	setReport(rep)
+
+	if prop != nil {
+		otel.SetTextMapPropagator(prop)
 	}
 
-	return tp.Tracer(lib), clean, nil
+	return ctx, tp.Tracer(lib), clean, rep.clone(), nil
 }
 
 func getEnv(caller, key string, debug bool) string {
@@ -113,6 +492,19 @@ func getEnv(caller, key string, debug bool) string {
 	return value
 }
 
+// getEnvBool retrieves boolean env var. Empty or invalid value means false.
+func getEnvBool(caller, key string, debug bool) bool {
+	str := getEnv(caller, key, debug)
+	if str == "" {
+		return false
+	}
+	value, err := strconv.ParseBool(str)
+	if err != nil {
+		log.Printf("%s: bad %s='%s': %v", caller, key, str, err)
+	}
+	return value
+}
+
 /*
 Open Telemetry tracing with Gin:
 
@@ -128,55 +520,325 @@ Open Telemetry tracing with Gin:
    resp, errGet := client.Do(req)
 */
 
-// tracerProvider creates a trace provider.
+// tracerProvider creates a trace provider, recording resource and
+// sampler in rep.
 // Service name precedence from higher to lower:
 // 1. OTEL_SERVICE_NAME=mysrv
 // 2. OTEL_RESOURCE_ATTRIBUTES=service.name=mysrv
 // 3. defaultService="mysrv"
-func tracerProvider(defaultService, exporter, otelEndpoint string, debug bool) (*tracesdk.TracerProvider, error) {
+func tracerProvider(options TraceOptions, cfg exportConfig, destinations []exportConfig, rep *Report) (*tracesdk.TracerProvider, error) {
 
 	const me = "tracerProvider"
 
+	defaultService := options.DefaultService
+	debug := options.Debug
+
 	if debug {
-		log.Printf("%s: service='%s' exporter='%s'", me, defaultService, exporter)
+		log.Printf("%s: service='%s' exporter='%s'", me, defaultService, cfg.exporter)
 	}
 
-	// Create the Jaeger exporter
-	exp, err := createExporter(exporter, otelEndpoint, debug)
+	lambda := lambdaMode(options)
+
+	attrs := append([]attribute.KeyValue{}, options.ResourceAttributes...)
+
+	if lambda {
+		attrs = append(attrs, lambdaAttributes()...)
+	}
+
+	if defaultService != "" && !hasServiceEnvVar(debug) {
+		attrs = append(attrs, semconv.ServiceNameKey.String(defaultService))
+	}
+
+	// OTEL_RESOURCE_ATTRIBUTES overrides attributes given in options.
+	rsrc, errMerge := resource.Merge(
+		resource.NewWithAttributes(semconv.SchemaURL, attrs...),
+		resource.Environment(),
+	)
+	if errMerge != nil {
+		return nil, errMerge
+	}
+
+	rep.Resource = rsrc.Attributes()
+
+	providerOptions := []tracesdk.TracerProviderOption{
+		// Record information about this application in a Resource.
+		tracesdk.WithResource(rsrc),
+	}
+
+	if syncExportEnabled(options) {
+		log.Printf("%s: WARNING: synchronous export of every span is enabled: for troubleshooting only, NOT FOR PRODUCTION", me)
+	}
+
+	export, err := exportProcessor(options, cfg, lambda, spoolConfigFromOptions(options),
+		expvarEnabled(options))
 	if err != nil {
 		return nil, err
 	}
 
-	var rsrc *resource.Resource
+	if len(destinations) > 0 {
+		fanout := fanoutProcessor{processors: []tracesdk.SpanProcessor{export}}
+		for _, dest := range destinations {
+			// stats for the main exporter only, since destinations
+			// receive the same spans
+			p, errDest := exportProcessor(options, dest, lambda, spoolConfig{}, false)
+			if errDest != nil {
+				fanout.Shutdown(context.Background()) // release processors already built
+				return nil, fmt.Errorf("%s: %s: %w", me, dest.endpointSource, errDest)
+			}
+			fanout.processors = append(fanout.processors, p)
+		}
+		export = fanout
+	}
+
+	if limit := attributeValueLengthLimit(options); limit > 0 {
+		export = truncateProcessor{SpanProcessor: export, limit: limit}
+		limits := tracesdk.NewSpanLimits()
+		limits.AttributeValueLengthLimit = limit
+		providerOptions = append(providerOptions, tracesdk.WithRawSpanLimits(limits))
+	}
+
+	if options.OnEnd != nil {
+		export = transformProcessor{SpanProcessor: export, transform: options.OnEnd}
+	}
+
+	providerOptions = append(providerOptions, tracesdk.WithSpanProcessor(export))
+
+	if options.IDGenerator != nil {
+		providerOptions = append(providerOptions, tracesdk.WithIDGenerator(options.IDGenerator))
+	}
+
+	rep.EnvSampler = samplerFromEnv(false).Description()
+
+	// span counter and span metrics must see spans not sampled
+	allSpans := options.SpanCounter || options.SpanMetrics
 
-	if defaultService == "" || hasServiceEnvVar(debug) {
-		rsrc = resource.NewWithAttributes(
-			semconv.SchemaURL,
-			//attribute.String("environment", environment),
-			//attribute.Int64("ID", id),
-		)
+	if options.SamplerFunc != nil || allSpans {
+		var sampler tracesdk.Sampler
+		if options.SamplerFunc != nil {
+			sampler = funcSampler{decide: options.SamplerFunc}
+		} else {
+			sampler = samplerFromEnv(debug)
+		}
+		if allSpans {
+			sampler = recordOnlySampler{Sampler: sampler}
+		}
+		providerOptions = append(providerOptions, tracesdk.WithSampler(sampler))
+		rep.Sampler = sampler.Description()
 	} else {
-		rsrc = resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceNameKey.String(defaultService),
-			//attribute.String("environment", environment),
-			//attribute.Int64("ID", id),
-		)
+		// the SDK builds the same sampler from env vars
+		rep.Sampler = rep.EnvSampler
 	}
 
-	tp := tracesdk.NewTracerProvider(
-		// Always be sure to batch in production.
-		tracesdk.WithBatcher(exp),
-		// Record information about this application in a Resource.
-		tracesdk.WithResource(rsrc),
-	)
+	if allSpans {
+		metrics, errMetrics := newSpanMetrics(options.SpanCounter, options.SpanMetrics)
+		if errMetrics != nil {
+			return nil, errMetrics
+		}
+		providerOptions = append(providerOptions, tracesdk.WithSpanProcessor(metrics))
+	}
+
+	tp := tracesdk.NewTracerProvider(providerOptions...)
 
 	return tp, nil
 }
 
-func createExporter(exporter, otelEndpoint string, debug bool) (tracesdk.SpanExporter, error) {
+// exportProcessor creates the exporter for cfg and the span processor
+// feeding it. Empty spool.dir disables the disk buffer. withStats
+// records pipeline stats in expvar.
+func exportProcessor(options TraceOptions, cfg exportConfig, lambda bool, spool spoolConfig, withStats bool) (tracesdk.SpanProcessor, error) {
+	exp, err := createExporter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if spool.dir != "" {
+		spoolExp, errSpool := newSpoolExporter(exp, spool, options.Debug)
+		if errSpool != nil {
+			return nil, errSpool
+		}
+		exp = spoolExp
+	}
+
+	if withStats {
+		exp = statsExporter{SpanExporter: exp, stats: expvarStats()}
+	}
+
+	var export tracesdk.SpanProcessor
+	var capacity int64 // no queue
+	switch {
+	case lambda:
+		// Lambda may freeze the environment before the batcher runs.
+		export = tracesdk.NewSimpleSpanProcessor(exp)
+	case syncExportEnabled(options):
+		export = tracesdk.NewSimpleSpanProcessor(exp)
+	default:
+		// Always be sure to batch in production.
+		export = tracesdk.NewBatchSpanProcessor(exp)
+		capacity = batchCapacity(options.Debug)
+	}
+
+	if withStats {
+		export = &statsProcessor{SpanProcessor: export, stats: expvarStats(), capacity: capacity}
+	}
+
+	return export, nil
+}
+
+// tlsReloadInterval resolves the TLS certificate reload interval from
+// OTELCONFIG_TLS_RELOAD_INTERVAL or options.
+func tlsReloadInterval(options TraceOptions) time.Duration {
+	const me = "tlsReloadInterval"
+	str := getEnv(me, "OTELCONFIG_TLS_RELOAD_INTERVAL", options.Debug)
+	if str == "" {
+		return options.TLSReloadInterval
+	}
+	interval, err := time.ParseDuration(str)
+	if err != nil {
+		log.Printf("%s: bad OTELCONFIG_TLS_RELOAD_INTERVAL='%s': %v", me, str, err)
+		return options.TLSReloadInterval
+	}
+	return interval
+}
+
+// syncExportEnabled tells whether synchronous export is enabled by
+// options or by OTELCONFIG_SYNC_EXPORT.
+func syncExportEnabled(options TraceOptions) bool {
+	return options.SyncExport || getEnvBool("syncExportEnabled", "OTELCONFIG_SYNC_EXPORT", options.Debug)
+}
+
+// selectExporter picks the exporter type. Precedence from higher to lower:
+// 1. OTELCONFIG_EXPORTER=grpc|http|jaeger|stdout
+// 2. OTEL_EXPORTER_OTLP_TRACES_PROTOCOL=grpc|http/protobuf
+// 3. OTEL_EXPORTER_OTLP_PROTOCOL=grpc|http/protobuf
+// 4. TraceOptions.Exporter
+func selectExporter(options TraceOptions) (string, error) {
+	const me = "selectExporter"
+
+	if exporter := getEnv(me, "OTELCONFIG_EXPORTER", options.Debug); exporter != "" {
+		return exporter, nil
+	}
+
+	for _, key := range []string{"OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", "OTEL_EXPORTER_OTLP_PROTOCOL"} {
+		protocol := getEnv(me, key, options.Debug)
+		switch protocol {
+		case "":
+			continue
+		case "grpc":
+			return "grpc", nil
+		case "http/protobuf":
+			return "http", nil
+		}
+		return "", fmt.Errorf("%s: unsupported %s='%s', supported: grpc, http/protobuf",
+			me, key, protocol)
+	}
+
+	return options.Exporter, nil
+}
+
+// discoveryEnabled tells whether collector discovery is enabled by
+// options or by OTELCONFIG_DISCOVER_COLLECTOR.
+func discoveryEnabled(options TraceOptions, caller string) bool {
+	return options.DiscoverCollector || getEnvBool(caller, "OTELCONFIG_DISCOVER_COLLECTOR", options.Debug)
+}
+
+// exportConfig holds the exporter settings resolved from env vars and options.
+type exportConfig struct {
+	exporter         string
+	endpoint         string
+	endpointSource   string // where endpoint came from, for error messages
+	endpointExplicit bool   // endpoint must be passed to client, since it is not the env var
+	headers          map[string]string
+	headersExplicit  bool        // headers replace OTEL_EXPORTER_OTLP_HEADERS, even if empty
+	tlsConfig        *tls.Config // nil means insecure
+	debug            bool
+}
+
+// resolveEndpoint picks the endpoint from env var, options, $(VAR)
+// expansion or collector discovery, in this order.
+func (c *exportConfig) resolveEndpoint(options TraceOptions) {
+	const me = "resolveEndpoint"
+
+	// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is used by the SDK as is,
+	// overriding options, expansion and discovery (jaeger does not use it)
+	if c.exporter != "jaeger" &&
+		getEnv(me, "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", options.Debug) != "" {
+		return
+	}
+
+	if c.endpoint == "" && options.Endpoint != "" {
+		c.endpoint = options.Endpoint
+		c.endpointSource = "TraceOptions.Endpoint"
+		c.endpointExplicit = true
+	}
+	if expanded, found := expandEndpoint(c.endpoint, options.Debug); found {
+		c.endpoint = expanded
+		c.endpointExplicit = true
+	}
+	if c.endpoint == "" && discoveryEnabled(options, me) {
+		service := options.CollectorService
+		if service == "" {
+			service = "opentelemetry-collector"
+		}
+		if discovered := discoverCollector(service, c.exporter, options.Debug); discovered != "" {
+			log.Printf("%s: discovered collector endpoint: %s", me, discovered)
+			c.endpoint = discovered
+			c.endpointSource = "collector discovery"
+			c.endpointExplicit = true
+		}
+	}
+}
+
+// headersWithEnv returns headers overlaid with the headers from
+// OTEL_EXPORTER_OTLP_TRACES_HEADERS, or else OTEL_EXPORTER_OTLP_HEADERS,
+// since WithHeaders replaces the headers the SDK reads from env vars.
+// Env vars take precedence over headers set by options and presets.
+func headersWithEnv(headers map[string]string) map[string]string {
+	const me = "headersWithEnv"
+
+	if len(headers) == 0 {
+		return headers // the SDK reads env vars
+	}
+
+	// not logged, since headers carry credentials
+	str := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_HEADERS")
+	if str == "" {
+		str = os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")
+	}
+	if str == "" {
+		return headers
+	}
+
+	merged := maps.Clone(headers)
+
+	// same format parsed by the SDK: key1=value1,key2=value2
+	for _, pair := range strings.Split(str, ",") {
+		k, v, found := strings.Cut(pair, "=")
+		key := strings.TrimSpace(k)
+		if !found || key == "" {
+			log.Printf("%s: ignoring bad OTLP header: missing key or '='", me)
+			continue
+		}
+		value, err := url.PathUnescape(v)
+		if err != nil {
+			log.Printf("%s: ignoring bad OTLP header '%s': %v", me, key, err)
+			continue
+		}
+		// header names are case insensitive
+		for existing := range merged {
+			if strings.EqualFold(existing, key) {
+				delete(merged, existing)
+			}
+		}
+		merged[key] = strings.TrimSpace(value)
+	}
+
+	return merged
+}
+
+func createExporter(cfg exportConfig) (tracesdk.SpanExporter, error) {
 	const me = "createExporter"
-	switch exporter {
+	otelEndpoint := cfg.endpoint
+	switch cfg.exporter {
 	case "jaeger":
 		// JaegerURL:          env.String("JAEGER_URL", "http://jaeger-collector:14268/api/traces"),
 		// exp, err := jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(url)))
@@ -187,25 +849,54 @@ func createExporter(exporter, otelEndpoint string, debug bool) (tracesdk.SpanExp
 		if errJoin != nil {
 			return nil, errJoin
 		}
-		if debug {
+		if cfg.debug {
 			log.Printf("%s: jaeger endpoint: %s", me, jaegerEndpoint)
 		}
 		return jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(jaegerEndpoint)))
 	case "", "grpc":
-		client := otlptracegrpc.NewClient(
-			otlptracegrpc.WithInsecure(),
-		)
+		var grpcOptions []otlptracegrpc.Option
+		// endpoint URL first, since it resets the insecure flag from its scheme
+		if cfg.endpointExplicit {
+			grpcOptions = append(grpcOptions, otlptracegrpc.WithEndpointURL(otelEndpoint))
+		}
+		if cfg.tlsConfig == nil {
+			grpcOptions = append(grpcOptions, otlptracegrpc.WithInsecure())
+		} else {
+			grpcOptions = append(grpcOptions,
+				otlptracegrpc.WithTLSCredentials(credentials.NewTLS(cfg.tlsConfig)))
+		}
+		if len(cfg.headers) > 0 || cfg.headersExplicit {
+			grpcOptions = append(grpcOptions, otlptracegrpc.WithHeaders(cfg.headers))
+		}
+		client := otlptracegrpc.NewClient(grpcOptions...)
 		return otlptrace.New(context.Background(), client)
 	case "http":
-		client := otlptracehttp.NewClient(
-			otlptracehttp.WithInsecure(),
-		)
+		var httpOptions []otlptracehttp.Option
+		// endpoint URL first, since it resets the insecure flag from its scheme
+		if cfg.endpointExplicit {
+			// base URL like OTEL_EXPORTER_OTLP_ENDPOINT, while
+			// WithEndpointURL takes the path as is
+			tracesURL, errJoin := url.JoinPath(otelEndpoint, "v1/traces")
+			if errJoin != nil {
+				return nil, errJoin
+			}
+			httpOptions = append(httpOptions, otlptracehttp.WithEndpointURL(tracesURL))
+		}
+		if cfg.tlsConfig == nil {
+			httpOptions = append(httpOptions, otlptracehttp.WithInsecure())
+		} else {
+			httpOptions = append(httpOptions, otlptracehttp.WithTLSClientConfig(cfg.tlsConfig))
+		}
+		if len(cfg.headers) > 0 || cfg.headersExplicit {
+			httpOptions = append(httpOptions, otlptracehttp.WithHeaders(cfg.headers))
+		}
+		client := otlptracehttp.NewClient(httpOptions...)
 		return otlptrace.New(context.Background(), client)
 	case "stdout":
 		return stdouttrace.New()
 	}
 	return nil, fmt.Errorf("%s: unrecognized exporter type: '%s'",
-		me, exporter)
+		me, cfg.exporter)
 
 }
 
@@ -235,8 +926,8 @@ func hasServiceEnvVar(debug bool) bool {
 	return false
 }
 
-// tracePropagation enables trace propagation.
-func tracePropagation(debug bool) {
+// tracePropagation builds the propagator for trace propagation.
+func tracePropagation(options TraceOptions) (propagation.TextMapPropagator, error) {
 	/*
 		// In order to propagate trace context over the wire, a propagator must be registered with the OpenTelemetry API.
 		// https://opentelemetry.io/docs/instrumentation/go/manual/
@@ -251,7 +942,37 @@ func tracePropagation(debug bool) {
 
 	const me = "tracePropagation"
 
-	prop := autoprop.NewTextMapPropagator(propagation.TraceContext{})
+	debug := options.Debug
+
+	var defaultProp propagation.TextMapPropagator = propagation.TraceContext{}
+	if len(options.DefaultPropagators) > 0 {
+		p, err := autoprop.TextMapPropagator(options.DefaultPropagators...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: default propagators: %w", me, err)
+		}
+		defaultProp = p
+	}
+
+	// OTEL_PROPAGATORS overrides the default
+	prop := autoprop.NewTextMapPropagator(defaultProp)
+
+	inject := options.InjectPropagators
+	if str := getEnv(me, "OTELCONFIG_PROPAGATORS_INJECT", debug); str != "" {
+		inject = strings.FieldsFunc(str, func(c rune) bool { return c == ',' || c == ' ' })
+	}
+
+	if len(inject) > 0 {
+		extra, err := autoprop.TextMapPropagator(inject...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: inject propagators: %w", me, err)
+		}
+		// the last propagator wins on extraction: OTEL_PROPAGATORS takes precedence
+		prop = propagation.NewCompositeTextMapPropagator(extra, prop)
+	}
+
+	if limits := baggageLimitsFromOptions(options); limits.enabled() {
+		prop = baggageLimitPropagator{TextMapPropagator: prop, limits: limits}
+	}
 
 	if debug {
 		fields := prop.Fields()
@@ -259,5 +980,5 @@ func tracePropagation(debug bool) {
 		log.Printf("%s: propagator fields: %v", me, fields)
 	}
 
-	otel.SetTextMapPropagator(prop)
+	return prop, nil
 }
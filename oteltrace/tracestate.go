@@ -0,0 +1,47 @@
+package oteltrace
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceStateGet returns the value of the W3C tracestate entry key from
+// the span context in ctx.
+func TraceStateGet(ctx context.Context, key string) (string, bool) {
+	ts := trace.SpanContextFromContext(ctx).TraceState()
+	value := ts.Get(key)
+	return value, value != ""
+}
+
+// TraceStateInsert returns a copy of ctx where the span context carries
+// the tracestate entry key=value, for instance a vendor entry with the
+// sampling tier, which is then propagated downstream:
+//
+//	ctx, err := oteltrace.TraceStateInsert(ctx, "acme", "tier:gold")
+//	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+//
+// Spans started from the returned context inherit the entry.
+// The key and value must follow the W3C tracestate format.
+//
+// The returned context carries the span context only: use the original
+// span to record attributes, events or to end it.
+func TraceStateInsert(ctx context.Context, key, value string) (context.Context, error) {
+	sc := trace.SpanContextFromContext(ctx)
+	ts, err := sc.TraceState().Insert(key, value)
+	if err != nil {
+		return ctx, err
+	}
+	return trace.ContextWithSpanContext(ctx, sc.WithTraceState(ts)), nil
+}
+
+// TraceStateDelete returns a copy of ctx where the span context does not
+// carry the tracestate entry key. See TraceStateInsert.
+func TraceStateDelete(ctx context.Context, key string) context.Context {
+	sc := trace.SpanContextFromContext(ctx)
+	ts := sc.TraceState()
+	if ts.Get(key) == "" {
+		return ctx
+	}
+	return trace.ContextWithSpanContext(ctx, sc.WithTraceState(ts.Delete(key)))
+}